@@ -0,0 +1,285 @@
+// companions.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// Start triggers for Companion.StartTrigger.
+	triggerDelay   = "delay"
+	triggerProcess = "process"
+	triggerLogLine = "log_line"
+
+	defaultCompanionDelay = 5   // seconds
+	defaultReadyTimeout   = 120 // seconds; a Steam Deck cold start can take well over a minute
+	bakkesModName         = "BakkesMod"
+
+	triggerPollInterval = 500 * time.Millisecond
+)
+
+// Companion is an app started alongside the game, such as an overlay or tracker.
+type Companion struct {
+	Name         string            `json:"name"`
+	Path         string            `json:"path"`
+	Args         []string          `json:"args,omitempty"`
+	Env          map[string]string `json:"env,omitempty"`
+	Disabled     bool              `json:"disabled,omitempty"`
+	StartTrigger string            `json:"start_trigger,omitempty"` // "process" (default), "log_line" or "delay"
+	Delay        int               `json:"delay,omitempty"`         // Seconds, for "delay" and when readiness can't be detected
+	ReadyTimeout int               `json:"ready_timeout,omitempty"` // Seconds to wait for readiness before starting anyway
+	ProcessName  string            `json:"process_name,omitempty"`  // For "process"; defaults to RocketLeague.exe
+	LogFile      string            `json:"log_file,omitempty"`      // For "log_line"; defaults to the game's Launch.log
+	LogLine      string            `json:"log_line,omitempty"`      // Text to wait for, for "log_line"
+	DisablesEAC  bool              `json:"disables_eac,omitempty"`  // Launch the game without EAC while enabled
+	KillOnExit   bool              `json:"kill_on_exit,omitempty"`  // Stop the companion when the game exits
+}
+
+// companionsDisableEAC reports whether any enabled companion needs the game to run without EAC.
+func companionsDisableEAC(companions []Companion) bool {
+	for _, c := range companions {
+		if !c.Disabled && c.DisablesEAC {
+			return true
+		}
+	}
+	return false
+}
+
+// findCompanion returns the companion with the given name, ignoring case.
+func findCompanion(companions []Companion, name string) *Companion {
+	for i := range companions {
+		if strings.EqualFold(companions[i].Name, name) {
+			return &companions[i]
+		}
+	}
+	return nil
+}
+
+// newBakkesModCompanion describes BakkesMod, which is blocked by EAC. It starts once
+// the game process is up, with the old fixed delay as the fallback.
+func newBakkesModCompanion(path string, delay int, enabled bool) Companion {
+	if delay <= 0 {
+		delay = defaultCompanionDelay
+	}
+	return Companion{
+		Name:         bakkesModName,
+		Path:         path,
+		Disabled:     !enabled,
+		StartTrigger: triggerProcess,
+		Delay:        delay,
+		DisablesEAC:  true,
+	}
+}
+
+// migrateBakkesModConfig moves the legacy bakkesmod_* settings into a companion entry.
+// It returns true if the config changed.
+func migrateBakkesModConfig(cfg *Config) bool {
+	if cfg.BakkesModPath == "" {
+		return false
+	}
+	if findCompanion(cfg.Companions, bakkesModName) == nil {
+		log.Println("Migrating BakkesMod settings to the companions list.")
+		cfg.Companions = append(cfg.Companions, newBakkesModCompanion(cfg.BakkesModPath, cfg.BakkesModLaunchDelay, cfg.BakkesModEnabled))
+	}
+	cfg.BakkesModPath = ""
+	cfg.BakkesModEnabled = false
+	cfg.BakkesModLaunchDelay = 0
+	return true
+}
+
+// startCompanions starts each enabled companion in the background once its trigger
+// fires. Companions whose trigger has not fired by the time the game exits are skipped.
+func startCompanions(cfg Config, session *gameSession) {
+	for _, c := range cfg.Companions {
+		if c.Disabled || c.Path == "" {
+			continue
+		}
+		c := c
+		if c.LogFile == "" {
+			c.LogFile = launchLogPath(cfg)
+		}
+		logStart := logEnd(c.LogFile)
+		if c.LogFile == session.launchLog {
+			logStart = session.launchLogStart
+		}
+		go func() {
+			if !waitForTrigger(c, session.ended, logStart) {
+				log.Printf("Rocket League exited before %s was started.", c.Name)
+				return
+			}
+			startCompanion(cfg, c, session)
+		}()
+	}
+}
+
+func startCompanion(cfg Config, c Companion, session *gameSession) {
+	log.Printf("Launching %s...", c.Name)
+	cmd, err := runnerCommand(cfg.Runner, c.Path, c.Args...)
+	if err == nil {
+		if len(c.Env) > 0 {
+			if cmd.Env == nil {
+				cmd.Env = os.Environ()
+			}
+			for _, key := range sortedKeys(c.Env) {
+				cmd.Env = append(cmd.Env, key+"="+os.ExpandEnv(c.Env[key]))
+			}
+		}
+		var p *trackedProcess
+		if p, err = startTracked(c.Name, cmd); err == nil {
+			p.stopWithGame = c.KillOnExit
+			session.addCompanion(p)
+			log.Printf("%s process started.", c.Name)
+			return
+		}
+	}
+
+	// Inform the user but do not treat it as a fatal error for the game itself.
+	errorMsg := fmt.Sprintf(
+		"Could not start %s at the specified path:\n\n%s\n\nError: %v\n\nRocket League should still be running.",
+		c.Name, c.Path, err,
+	)
+	showError(c.Name+" Launch Failed", errorMsg)
+}
+
+// waitForTrigger blocks until the companion should start. Readiness triggers give up
+// after the ready timeout and start the companion anyway; if readiness can't be
+// observed at all, the fixed delay is used instead. It returns false if the game
+// exits first.
+func waitForTrigger(c Companion, gameDone <-chan struct{}, logStart logPosition) bool {
+	var ready func() bool
+	var signal string
+	switch strings.ToLower(c.StartTrigger) {
+	case triggerDelay:
+	case triggerLogLine:
+		if c.LogFile != "" && c.LogLine != "" {
+			signal = fmt.Sprintf("%q appears in %s", c.LogLine, c.LogFile)
+			ready = func() bool { return fileContainsSince(c.LogFile, logStart, c.LogLine) }
+		} else {
+			log.Printf("%s: no log file or log line to wait for, using the fixed delay.", c.Name)
+		}
+	default: // triggerProcess
+		// The game process, not the EAC bootstrapper (RocketLeague_EAC.exe), shows the game is up.
+		name := orDefault(c.ProcessName, "RocketLeague.exe")
+		if canListProcesses() {
+			signal = name + " is running"
+			ready = func() bool { return len(findProcesses(name)) > 0 }
+		} else {
+			log.Printf("%s: running processes can't be listed on this system, using the fixed delay.", c.Name)
+		}
+	}
+
+	if ready == nil {
+		delay := time.Duration(c.Delay) * time.Second
+		if c.Delay <= 0 {
+			delay = defaultCompanionDelay * time.Second
+		}
+		slog.Debug("Companion start scheduled", "companion", c.Name, "delay", delay)
+		select {
+		case <-time.After(delay):
+			return true
+		case <-gameDone:
+			return false
+		}
+	}
+
+	timeout := time.Duration(c.ReadyTimeout) * time.Second
+	if c.ReadyTimeout <= 0 {
+		timeout = defaultReadyTimeout * time.Second
+	}
+	slog.Debug("Companion waiting for the game", "companion", c.Name, "trigger", signal, "timeout", timeout)
+	switch pollUntil(gameDone, timeout, ready) {
+	case waitReady:
+		return true
+	case waitTimedOut:
+		log.Printf("%s: no sign that the game is ready after %v, starting it anyway.", c.Name, timeout)
+		return true
+	default:
+		return false
+	}
+}
+
+// waitResult is how a wait for a start trigger ended.
+type waitResult int
+
+const (
+	waitReady waitResult = iota
+	waitTimedOut
+	waitGameExited
+)
+
+// pollUntil calls ready until it returns true, the timeout passes, or done is closed.
+func pollUntil(done <-chan struct{}, timeout time.Duration, ready func() bool) waitResult {
+	ticker := time.NewTicker(triggerPollInterval)
+	defer ticker.Stop()
+	deadline := time.After(timeout)
+	for {
+		if ready() {
+			return waitReady
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return waitTimedOut
+		case <-done:
+			return waitGameExited
+		}
+	}
+}
+
+// logPosition marks how far a log file had been read, and which file that was. A log
+// recreated since then is read from the start, even if it's already as large.
+type logPosition struct {
+	file   os.FileInfo // nil if the log didn't exist
+	offset int64
+}
+
+// logEnd returns the position at the current end of the log at path. It stats the
+// open file, since os.SameFile on Windows would otherwise look the path up again later.
+func logEnd(path string) logPosition {
+	if path == "" {
+		return logPosition{}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return logPosition{}
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return logPosition{}
+	}
+	return logPosition{file: info, offset: info.Size()}
+}
+
+// offsetIn returns where to continue reading the log described by info: the marked
+// offset if it's the same file, or 0 if the log was replaced or truncated.
+func (p logPosition) offsetIn(info os.FileInfo) int64 {
+	if p.file == nil || !os.SameFile(p.file, info) || info.Size() < p.offset {
+		return 0
+	}
+	return p.offset
+}
+
+// fileContainsSince reports whether text appears in the file after the given position.
+func fileContainsSince(path string, since logPosition, text string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	if _, err := f.Seek(since.offsetIn(info), io.SeekStart); err != nil {
+		return false
+	}
+	data, err := io.ReadAll(f)
+	return err == nil && strings.Contains(string(data), text)
+}
@@ -0,0 +1,44 @@
+// companions_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileContainsSince(t *testing.T) {
+	path := filepath.Join(t.TempDir(), launchLogFileName)
+	old := "Log: Ready from the last session\n" + strings.Repeat("Log: padding\n", 10)
+	if err := os.WriteFile(path, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+	start := logEnd(path)
+	if fileContainsSince(path, start, "Ready") {
+		t.Error("a line from before the launch was matched")
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("Log: Ready again\n")
+	f.Close()
+	if !fileContainsSince(path, start, "Ready again") {
+		t.Error("a line appended after the launch was missed")
+	}
+
+	// The game moves the old Launch.log aside and starts a new one, which here is
+	// already past the old offset.
+	if err := os.Rename(path, filepath.Join(filepath.Dir(path), "Launch-backup.log")); err != nil {
+		t.Fatal(err)
+	}
+	recreated := "Log: Engine Ready\n" + strings.Repeat("Log: more padding\n", 20)
+	if err := os.WriteFile(path, []byte(recreated), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !fileContainsSince(path, start, "Engine Ready") {
+		t.Error("the start of a recreated log was skipped")
+	}
+}
@@ -0,0 +1,175 @@
+// crash.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	crashReportsDirName = "crash-reports"
+	crashReportMaxDumps = 5
+	defaultMaxRestarts  = 2
+
+	// Policies for Config.CrashRestart.
+	crashRestartAsk  = "ask"
+	crashRestartAuto = "auto"
+	crashRestartOff  = "off"
+)
+
+// exitKind classifies how the game exited.
+type exitKind string
+
+const (
+	exitClean  exitKind = "clean"
+	exitError  exitKind = "error" // Nonzero exit code without any sign of a crash
+	exitCrash  exitKind = "crash"
+	exitKilled exitKind = "killed"
+)
+
+// gameExit describes how a game session ended.
+type gameExit struct {
+	Kind   exitKind
+	Detail string
+	Uptime time.Duration
+	Dumps  []string // Crash dumps written during the session, newest first
+}
+
+// classifyExitCode classifies an ordinary exit code. A nonzero code alone is no sign
+// of a crash: runners, wrappers and taskkill /F all end with one.
+func classifyExitCode(code int) (exitKind, string) {
+	if code == 0 {
+		return exitClean, "exit code 0"
+	}
+	return exitError, fmt.Sprintf("exit code %d", code)
+}
+
+// classifyGameExit works out how the session's game ended. Only a fatal signal, an
+// exception code or a crash dump written during the session counts as a crash; for
+// an adopted game process, which has no exit code, the dumps are all there is to go
+// on. A game ended by 'Slipstream stop' counts as killed, whatever its exit code.
+func classifyGameExit(cfg Config, s *gameSession) gameExit {
+	exit := gameExit{Kind: exitClean, Detail: "exit code unknown", Uptime: time.Since(s.startedAt)}
+	if state := s.currentGame().exitState(); state != nil {
+		exit.Kind, exit.Detail = classifyExitState(state)
+	}
+	exit.Dumps = newCrashDumps(cfg, s.startedAt)
+	switch {
+	case stopRequested():
+		exit.Kind = exitKilled
+		exit.Detail += ", stopped by 'Slipstream stop'"
+	case (exit.Kind == exitClean || exit.Kind == exitError) && len(exit.Dumps) > 0:
+		exit.Kind = exitCrash
+		exit.Detail += ", crash dump written"
+	}
+	slog.Info("Game exited", "kind", exit.Kind, "detail", exit.Detail, "uptime", exit.Uptime.Round(time.Second))
+	return exit
+}
+
+// newCrashDumps returns the newest minidumps written since the given time. The game
+// (Unreal Engine 3) writes them next to its logs.
+func newCrashDumps(cfg Config, since time.Time) []string {
+	dir := rocketLeagueLogDir(cfg)
+	if dir == "" {
+		return nil
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.dmp"))
+	modTimes := map[string]time.Time{}
+	var dumps []string
+	for _, path := range matches {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(since) {
+			modTimes[path] = info.ModTime()
+			dumps = append(dumps, path)
+		}
+	}
+	sort.Slice(dumps, func(i, j int) bool { return modTimes[dumps[i]].After(modTimes[dumps[j]]) })
+	if len(dumps) > crashReportMaxDumps {
+		dumps = dumps[:crashReportMaxDumps]
+	}
+	return dumps
+}
+
+// collectCrashReport copies the crash dumps, Launch.log and slipstream.log into a new
+// timestamped folder under crash-reports, with a summary of the exit, and returns
+// the folder's path.
+func collectCrashReport(cfg Config, s *gameSession, exit gameExit) (string, error) {
+	dir := filepath.Join(getExecutableDir(), crashReportsDirName, time.Now().Format("2006-01-02_15-04-05"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	files := append([]string{}, exit.Dumps...)
+	if s.launchLog != "" {
+		files = append(files, s.launchLog)
+	}
+	files = append(files, filepath.Join(getExecutableDir(), logFileName))
+	for _, path := range files {
+		copyFn := copyFile
+		if strings.EqualFold(filepath.Ext(path), ".log") {
+			copyFn = copyRedacted
+		}
+		if err := copyFn(path, filepath.Join(dir, filepath.Base(path))); err != nil {
+			log.Printf("Warning: could not add %s to the crash report: %v", path, err)
+		}
+	}
+
+	summary := fmt.Sprintf("Slipstream %s\nGame: %s\nStarted: %s\nExit: %s (%s)\nUptime: %v\nCrash dumps: %d\n",
+		currentVersion, cfg.RocketLeaguePath, s.startedAt.Format(time.RFC3339),
+		exit.Kind, exit.Detail, exit.Uptime.Round(time.Second), len(exit.Dumps))
+	if err := os.WriteFile(filepath.Join(dir, "summary.txt"), []byte(summary), 0644); err != nil {
+		return dir, err
+	}
+	return dir, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// confirmRestart tells the user about a crash and decides whether to relaunch,
+// following the crash_restart policy and the max_restarts limit.
+func confirmRestart(cfg Config, exit gameExit, report string, restarts int) bool {
+	maxRestarts := cfg.MaxRestarts
+	if maxRestarts <= 0 {
+		maxRestarts = defaultMaxRestarts
+	}
+	message := fmt.Sprintf("Rocket League crashed after %v (%s).", exit.Uptime.Round(time.Second), exit.Detail)
+	if report != "" {
+		message += "\n\nA crash report with the game's logs and crash dumps was saved to:\n" + report
+	}
+
+	policy := strings.ToLower(cfg.CrashRestart)
+	if policy == crashRestartOff || restarts >= maxRestarts {
+		if policy != crashRestartOff {
+			message += fmt.Sprintf("\n\nThe game was already relaunched %d times, so Slipstream won't try again.", restarts)
+		}
+		showError("Rocket League Crashed", message)
+		return false
+	}
+	if policy == crashRestartAuto {
+		log.Printf("Rocket League crashed, relaunching (restart %d of %d).", restarts+1, maxRestarts)
+		return true
+	}
+	log.Printf("Warning: %s", message)
+	return askQuestion(question{Title: "Rocket League Crashed", Message: message + "\n\nDo you want to relaunch the game?", OK: "Relaunch", Cancel: "Close", Icon: iconWarning}) == answerOK
+}
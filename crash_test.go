@@ -0,0 +1,111 @@
+// crash_test.go
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestGameProcess stands in for the game: run by the tests below, it exits with the
+// code in SLIPSTREAM_TEST_EXIT_CODE.
+func TestGameProcess(t *testing.T) {
+	code := os.Getenv("SLIPSTREAM_TEST_EXIT_CODE")
+	if code == "" {
+		return
+	}
+	n, _ := strconv.Atoi(code)
+	os.Exit(n)
+}
+
+// runTestGame runs a stand-in game that exits with code and returns its session.
+func runTestGame(t *testing.T, code int) *gameSession {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestGameProcess$")
+	cmd.Env = append(os.Environ(), "SLIPSTREAM_TEST_EXIT_CODE="+strconv.Itoa(code))
+	game, err := startTracked("Rocket League", cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newGameSession(game, hookContext{})
+	s.startedAt = time.Now().Add(-time.Minute)
+	select {
+	case <-s.ended:
+	case <-time.After(30 * time.Second):
+		t.Fatal("the stand-in game did not exit")
+	}
+	return s
+}
+
+func TestClassifyGameExit(t *testing.T) {
+	useTempInstall(t)
+	t.Setenv("HOME", t.TempDir()) // No crash dumps in the game's log folder.
+	cfg := Config{RocketLeaguePath: filepath.Join(t.TempDir(), "RocketLeague")}
+
+	tests := []struct {
+		code int
+		want exitKind
+	}{
+		{0, exitClean},
+		{1, exitError}, // taskkill /F, or a runner's ordinary failure
+		{3, exitError},
+	}
+	for _, tt := range tests {
+		if got := classifyGameExit(cfg, runTestGame(t, tt.code)); got.Kind != tt.want {
+			t.Errorf("exit code %d classified as %s (%s), want %s", tt.code, got.Kind, got.Detail, tt.want)
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return // The log folder is the real Documents folder.
+	}
+	dir := rocketLeagueLogDir(cfg)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "crash.dmp"), []byte("MDMP"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := classifyGameExit(cfg, runTestGame(t, 1)); got.Kind != exitCrash || len(got.Dumps) != 1 {
+		t.Errorf("exit with a new crash dump classified as %s (%s), dumps %q", got.Kind, got.Detail, got.Dumps)
+	}
+}
+
+// TestStoppedGameIsNotRestarted checks that a game ended by 'Slipstream stop', which
+// force-kills it on Windows, isn't taken for a crash and relaunched.
+func TestStoppedGameIsNotRestarted(t *testing.T) {
+	useTempInstall(t)
+	t.Setenv("HOME", t.TempDir())
+	cfg := Config{RocketLeaguePath: filepath.Join(t.TempDir(), "RocketLeague"), CrashRestart: crashRestartAuto}
+	session := runTestGame(t, 1)
+	if err := writeInstanceLock(session); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := readInstanceLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := requestStop(lock); err != nil {
+		t.Fatal(err)
+	}
+
+	exit := classifyGameExit(cfg, session)
+	if exit.Kind != exitKilled {
+		t.Errorf("stopped game classified as %s (%s), want %s", exit.Kind, exit.Detail, exitKilled)
+	}
+	removeInstanceLock()
+	if fileExists(stopRequestPath()) || fileExists(instanceLockPath()) {
+		t.Error("the stop request or lock file was left behind")
+	}
+	// Another instance's stop request doesn't apply to this one.
+	if err := requestStop(instanceLock{PID: os.Getpid() + 1}); err != nil {
+		t.Fatal(err)
+	}
+	if stopRequested() {
+		t.Error("a stop request for another instance applied to this one")
+	}
+}
@@ -0,0 +1,329 @@
+// doctor.go
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	doctorZipFlag = "--zip"
+	doctorTimeout = 10 * time.Second
+
+	// Check results.
+	doctorPass = "PASS"
+	doctorWarn = "WARN"
+	doctorFail = "FAIL"
+)
+
+// doctorResult is the outcome of one doctor check.
+type doctorResult struct {
+	Status string
+	Name   string
+	Detail string
+}
+
+// doctorReport collects check results in order.
+type doctorReport struct {
+	results []doctorResult
+}
+
+func (r *doctorReport) add(status, name, format string, args ...any) {
+	r.results = append(r.results, doctorResult{Status: status, Name: name, Detail: fmt.Sprintf(format, args...)})
+}
+
+func (r *doctorReport) count(status string) int {
+	n := 0
+	for _, res := range r.results {
+		if res.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *doctorReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Slipstream %s doctor report (%s/%s, %s)\n\n", currentVersion, runtime.GOOS, runtime.GOARCH, time.Now().Format(time.RFC3339))
+	for _, res := range r.results {
+		fmt.Fprintf(&b, "[%s] %s: %s\n", res.Status, res.Name, res.Detail)
+	}
+	fmt.Fprintf(&b, "\n%d passed, %d warnings, %d failed\n", r.count(doctorPass), r.count(doctorWarn), r.count(doctorFail))
+	return redact(b.String())
+}
+
+// runDoctorCommand checks the setup Slipstream depends on, prints the results and,
+// with --zip, writes them together with the config and logs to a redacted zip file.
+// It never requests an exchange code, so no launch is used up.
+func runDoctorCommand(args []string) error {
+	report := &doctorReport{}
+	cfg, cfgOK := doctorConfig(report)
+	if cfgOK {
+		doctorGamePath(report, cfg)
+		doctorEAC(report, cfg)
+		doctorRunner(report, cfg)
+	}
+	doctorPermissions(report)
+	doctorNetwork(report)
+	if cfgOK {
+		doctorToken(report, &cfg)
+	}
+	doctorDialogs(report, cfg)
+
+	text := report.String()
+	fmt.Print(text)
+	for _, res := range report.results {
+		slog.Debug("Doctor check", "status", res.Status, "check", res.Name, "detail", res.Detail)
+	}
+	log.Printf("Doctor finished: %d passed, %d warnings, %d failed.", report.count(doctorPass), report.count(doctorWarn), report.count(doctorFail))
+
+	summary := text
+	if hasFlag(args, doctorZipFlag) {
+		path, err := writeDoctorZip(cfg, text)
+		if err != nil {
+			summary += "\nCould not write the diagnostics zip: " + err.Error()
+		} else {
+			summary += "\nDiagnostics saved to:\n" + path
+			log.Printf("Diagnostics saved to %s", path)
+		}
+	}
+	showInfo("Slipstream Doctor", summary)
+
+	if n := report.count(doctorFail); n > 0 {
+		return fmt.Errorf("%d checks failed", n)
+	}
+	return nil
+}
+
+func doctorConfig(r *doctorReport) (Config, bool) {
+	path := filepath.Join(getExecutableDir(), getConfigFileName())
+	cfg, err := readConfigFile()
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		r.add(doctorFail, "Config", "%s does not exist; run Slipstream once to set it up", path)
+		return cfg, false
+	case err != nil:
+		r.add(doctorFail, "Config", "%s could not be read: %v", path, err)
+		return cfg, false
+	}
+	r.add(doctorPass, "Config", "%s loaded", path)
+	return cfg, true
+}
+
+func doctorGamePath(r *doctorReport, cfg Config) {
+	if cfg.RocketLeaguePath == "" {
+		r.add(doctorFail, "Game path", "rocket_league_path is not set")
+		return
+	}
+	dir := filepath.Dir(cfg.RocketLeaguePath)
+	missing := []string{}
+	for _, name := range []string{"RocketLeague.exe", eacBootstrapper} {
+		if !fileExists(filepath.Join(dir, name)) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		r.add(doctorFail, "Game path", "missing from %s: %s", dir, strings.Join(missing, ", "))
+		return
+	}
+	r.add(doctorPass, "Game path", "%s", dir)
+
+	info, err := findInstallInfo(cfg.RocketLeaguePath)
+	switch {
+	case err != nil:
+		r.add(doctorWarn, "Game install", "no launcher metadata found: %v", err)
+	case info.Incomplete:
+		r.add(doctorWarn, "Game install", "an update is pending or unfinished (%s)", info.Source)
+	default:
+		r.add(doctorPass, "Game install", "version %s (%s)", orDefault(info.Version, "unknown"), info.Source)
+	}
+}
+
+func doctorEAC(r *doctorReport, cfg Config) {
+	if cfg.RocketLeaguePath == "" {
+		return
+	}
+	problems := checkEAC(cfg, filepath.Dir(cfg.RocketLeaguePath))
+	if len(problems) == 0 {
+		r.add(doctorPass, "Easy Anti-Cheat", "installed")
+	}
+	for _, problem := range problems {
+		r.add(doctorWarn, "Easy Anti-Cheat", "%s", problem)
+	}
+}
+
+func doctorRunner(r *doctorReport, cfg Config) {
+	if runningUnderWine() {
+		r.add(doctorPass, "Runner", "running under Wine/Proton (prefix %s)", orDefault(os.Getenv("STEAM_COMPAT_DATA_PATH"), os.Getenv("WINEPREFIX")))
+		return
+	}
+	if !needsRunner(cfg.RocketLeaguePath) {
+		return
+	}
+	rc := cfg.Runner
+	switch strings.ToLower(rc.Type) {
+	case "":
+		r.add(doctorWarn, "Runner", "no runner is set, so this build only does the initial setup; run Slipstream.exe under Proton or set \"runner\"")
+		return
+	case runnerWine, runnerUmu:
+		bin := orDefault(rc.Path, map[string]string{runnerWine: "wine", runnerUmu: "umu-run"}[strings.ToLower(rc.Type)])
+		if path, err := exec.LookPath(bin); err != nil {
+			r.add(doctorFail, "Runner", "%s runner binary %q not found", rc.Type, bin)
+		} else {
+			r.add(doctorPass, "Runner", "%s (%s)", rc.Type, path)
+		}
+	case runnerProton:
+		proton := filepath.Join(rc.Path, "proton")
+		if !fileExists(proton) {
+			r.add(doctorFail, "Runner", "%s does not exist", proton)
+		} else {
+			version, _ := os.ReadFile(filepath.Join(rc.Path, "version"))
+			r.add(doctorPass, "Runner", "Proton %s (%s)", orDefault(strings.TrimSpace(string(version)), "unknown version"), rc.Path)
+		}
+	default:
+		r.add(doctorFail, "Runner", "unknown runner type %q", rc.Type)
+	}
+	if prefix := rc.winePrefix(); prefix != "" && !fileExists(prefix) {
+		r.add(doctorWarn, "Runner prefix", "%s does not exist yet", prefix)
+	}
+}
+
+func doctorPermissions(r *doctorReport) {
+	dir := getExecutableDir()
+	f, err := os.CreateTemp(dir, ".slipstream-doctor-*")
+	if err != nil {
+		r.add(doctorFail, "Permissions", "can't write to %s, so the config and log can't be saved: %v", dir, err)
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+	r.add(doctorPass, "Permissions", "%s is writable", dir)
+}
+
+func doctorNetwork(r *doctorReport) {
+	for _, endpoint := range []string{epicAPIURL, epicLauncherServiceURL, releasesURL} {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			continue
+		}
+		host := u.Hostname()
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+		_, err = net.DefaultResolver.LookupHost(ctx, host)
+		cancel()
+		if err != nil {
+			r.add(doctorFail, "Network", "can't resolve %s: %v", host, err)
+			continue
+		}
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: doctorTimeout}, "tcp", net.JoinHostPort(host, "443"), &tls.Config{ServerName: host})
+		if err != nil {
+			r.add(doctorFail, "Network", "can't connect to %s: %v", host, err)
+			continue
+		}
+		conn.Close()
+		r.add(doctorPass, "Network", "%s reachable (%v)", host, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// doctorToken checks that the saved refresh token still works. Epic rotates the
+// refresh token on use, so the new one is saved just as a launch would.
+func doctorToken(r *doctorReport, cfg *Config) {
+	if cfg.EpicToken == "" {
+		r.add(doctorWarn, "Epic login", "not logged in yet; run Slipstream once to log in")
+		return
+	}
+	resp, err := NewAuthenticator().exchangeRefreshToken(cfg.EpicToken)
+	if err != nil {
+		r.add(doctorFail, "Epic login", "the saved login no longer works, delete 'epic_token' from the config to log in again: %v", err)
+		return
+	}
+	r.add(doctorPass, "Epic login", "valid for %s", orDefault(resp.DisplayName, resp.AccountID))
+	saveEpicToken(cfg, resp.RefreshToken)
+}
+
+func doctorDialogs(r *doctorReport, cfg Config) {
+	name := chooseUIBackend(cfg)
+	switch {
+	case name == uiTerminal:
+		r.add(doctorPass, "Dialogs", "terminal prompts")
+		return
+	case name == uiScript:
+		r.add(doctorWarn, "Dialogs", "answered from %s instead of asking", orDefault(cfg.UIScript, "(ui_script is not set)"))
+		return
+	case name == uiZenity && runtime.GOOS != "linux":
+		r.add(doctorPass, "Dialogs", "native dialogs")
+		return
+	}
+	if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		r.add(doctorWarn, "Dialogs", "no graphical session (DISPLAY and WAYLAND_DISPLAY are unset)")
+	}
+	programs := zenityPrograms
+	if name == uiKDialog {
+		programs = []string{uiKDialog}
+	}
+	if path := findProgram(programs...); path != "" {
+		r.add(doctorPass, "Dialogs", "%s", path)
+		return
+	}
+	r.add(doctorFail, "Dialogs", "no dialog program found; install zenity or kdialog, or set \"ui\" to \"terminal\"")
+}
+
+// writeDoctorZip saves the report, the config and the logs, all redacted, to a zip
+// file next to the executable and returns its path. It includes as many rotated logs
+// as cfg keeps.
+func writeDoctorZip(cfg Config, report string) (string, error) {
+	dir := getExecutableDir()
+	path := filepath.Join(dir, "slipstream-doctor-"+time.Now().Format("2006-01-02_15-04-05")+".zip")
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	add := func(name, content string) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write([]byte(redact(content)))
+		return err
+	}
+	if err := add("doctor.txt", report); err != nil {
+		return "", err
+	}
+	files := []string{getConfigFileName(), logFileName}
+	maxFiles := cfg.LogMaxFiles
+	if maxFiles <= 0 {
+		maxFiles = defaultLogMaxFiles
+	}
+	for i := 1; i <= maxFiles; i++ {
+		files = append(files, fmt.Sprintf("%s.%d", logFileName, i))
+	}
+	for _, name := range files {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		if err := add(filepath.Base(name), string(data)); err != nil {
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return path, nil
+}
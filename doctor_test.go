@@ -0,0 +1,60 @@
+// doctor_test.go
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWriteDoctorZip(t *testing.T) {
+	exe := useTempInstall(t)
+	dir := filepath.Dir(exe)
+	cfg := Config{LogMaxFiles: 5, EpicToken: "eg1~savedRefreshToken"}
+	writeTestConfig(t, cfg)
+	logs := []string{logFileName}
+	for i := 1; i <= 6; i++ {
+		logs = append(logs, fmt.Sprintf("%s.%d", logFileName, i))
+	}
+	for _, name := range logs {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("log "+name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path, err := writeDoctorZip(cfg, "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	var names []string
+	contents := map[string]string{}
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		contents[f.Name] = string(data)
+	}
+
+	// All rotated logs the config keeps, but not the stray sixth one.
+	want := append([]string{"doctor.txt", configFileName}, logs[:6]...)
+	if !slices.Equal(names, want) {
+		t.Errorf("zip holds %q, want %q", names, want)
+	}
+	if got := contents[configFileName]; strings.Contains(got, "savedRefreshToken") {
+		t.Errorf("config in the zip is not redacted:\n%s", got)
+	}
+}
@@ -0,0 +1,127 @@
+// eac.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	eacBootstrapper  = "RocketLeague_EAC.exe"
+	eacDirName       = "EasyAntiCheat"
+	eacSettingsFile  = "Settings.json"
+	eacSetupFile     = "EasyAntiCheat_EOS_Setup.exe"
+	eacServiceDir    = "EasyAntiCheat_EOS"
+	eacServiceExe    = "EasyAntiCheat_EOS.exe"
+	eacRuntimeEnv    = "PROTON_EAC_RUNTIME"
+	eacRuntimeAppDir = "Proton EasyAntiCheat Runtime"
+
+	// Values for Config.EACCheck.
+	eacCheckWarn = "warn"
+	eacCheckOff  = "off"
+)
+
+// eacDecision is what to do after the EAC health check.
+type eacDecision int
+
+const (
+	eacLaunch eacDecision = iota
+	eacOffline
+	eacCancel
+)
+
+// checkEAC looks for anything that would stop Easy Anti-Cheat from starting the game
+// in rlDir, the game's Binaries/Win64 directory. Each problem says how to fix it.
+func checkEAC(cfg Config, rlDir string) []string {
+	var problems []string
+	if !fileExists(filepath.Join(rlDir, eacBootstrapper)) {
+		problems = append(problems, fmt.Sprintf("The EAC launcher '%s' is missing from %s. Verify the game files in your game manager.", eacBootstrapper, rlDir))
+	}
+	eacDir := filepath.Join(rlDir, eacDirName)
+	if !fileExists(eacDir) {
+		problems = append(problems, fmt.Sprintf("The '%s' folder is missing from %s. Verify the game files in your game manager.", eacDirName, rlDir))
+	} else {
+		for _, name := range []string{eacSettingsFile, eacSetupFile} {
+			if !fileExists(filepath.Join(eacDir, name)) {
+				problems = append(problems, fmt.Sprintf("'%s' is missing from %s. Verify the game files in your game manager.", name, eacDir))
+			}
+		}
+	}
+
+	switch {
+	case needsRunner(cfg.RocketLeaguePath):
+		if problem := checkEACRunner(cfg); problem != "" {
+			problems = append(problems, problem)
+		}
+	case runningUnderWine():
+		if os.Getenv(eacRuntimeEnv) == "" {
+			problems = append(problems, "Slipstream is running under Proton, but the Proton EasyAntiCheat Runtime is not enabled. "+
+				"Install 'Proton EasyAntiCheat Runtime' in Steam (Library > Tools) and add "+eacRuntimeEnv+"=<its folder> %command% to Slipstream's launch options.")
+		}
+	case runtime.GOOS == "windows":
+		service := filepath.Join(os.Getenv("ProgramFiles(x86)"), eacServiceDir, eacServiceExe)
+		if !fileExists(service) && fileExists(filepath.Join(eacDir, eacSetupFile)) {
+			problems = append(problems, fmt.Sprintf("The Easy Anti-Cheat service is not installed. Run '%s' from %s and choose Install.", eacSetupFile, eacDir))
+		}
+	}
+	return problems
+}
+
+// checkEACRunner checks that the configured runner can run Easy Anti-Cheat.
+func checkEACRunner(cfg Config) string {
+	switch strings.ToLower(cfg.Runner.Type) {
+	case runnerWine:
+		return "Plain Wine can't run Easy Anti-Cheat. Use the \"proton\" or \"umu\" runner instead."
+	case runnerProton:
+		if cfg.Env[eacRuntimeEnv] != "" || os.Getenv(eacRuntimeEnv) != "" {
+			return ""
+		}
+		runtimeDir := filepath.Join(steamRootDir(), "steamapps", "common", eacRuntimeAppDir)
+		if fileExists(runtimeDir) {
+			return fmt.Sprintf("Proton needs the EasyAntiCheat runtime to be enabled. Add \"%s\": %q to \"env\" in 'config.json'.", eacRuntimeEnv, runtimeDir)
+		}
+		return "The Proton EasyAntiCheat Runtime is not installed. Install 'Proton EasyAntiCheat Runtime' in Steam (Library > Tools), " +
+			"then add \"" + eacRuntimeEnv + "\": \"<its folder>\" to \"env\" in 'config.json'."
+	}
+	return "" // umu-run sets up the runtime itself.
+}
+
+// runningUnderWine reports whether this Windows build runs under Wine or Proton.
+func runningUnderWine() bool {
+	return runtime.GOOS == "windows" && (os.Getenv("WINEPREFIX") != "" || os.Getenv("STEAM_COMPAT_DATA_PATH") != "")
+}
+
+// confirmEAC runs the EAC health check and, if it finds problems, asks whether to
+// launch offline without EAC, launch anyway, or cancel. Dry runs only log the problems.
+func confirmEAC(cfg Config, rlDir string, dryRun bool) eacDecision {
+	if strings.ToLower(cfg.EACCheck) == eacCheckOff {
+		return eacLaunch
+	}
+	problems := checkEAC(cfg, rlDir)
+	if len(problems) == 0 {
+		return eacLaunch
+	}
+	for _, problem := range problems {
+		log.Printf("Warning: Easy Anti-Cheat: %s", problem)
+	}
+	if dryRun {
+		return eacLaunch
+	}
+
+	message := "Easy Anti-Cheat doesn't look ready, so online play will probably fail:\n\n• " +
+		strings.Join(problems, "\n\n• ") +
+		"\n\nYou can play offline without Easy Anti-Cheat instead."
+	switch askQuestion(question{Title: "Easy Anti-Cheat Problem", Message: message, OK: "Play Offline", Extra: "Launch Anyway", Cancel: "Cancel", Icon: iconWarning}) {
+	case answerOK:
+		log.Println("Falling back to offline mode without Easy Anti-Cheat.")
+		return eacOffline
+	case answerExtra:
+		return eacLaunch
+	default:
+		return eacCancel
+	}
+}
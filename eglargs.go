@@ -0,0 +1,151 @@
+// eglargs.go
+package main
+
+import (
+	"strings"
+)
+
+const (
+	// Values for Config.Language besides explicit Rocket League language codes.
+	languageAuto = "auto"
+	languageOff  = "off"
+)
+
+// latinAmericanRegions use Rocket League's Latin American Spanish localisation.
+var latinAmericanRegions = map[string]bool{
+	"419": true, "AR": true, "BO": true, "CL": true, "CO": true, "CR": true, "CU": true, "DO": true,
+	"EC": true, "GT": true, "HN": true, "MX": true, "NI": true, "PA": true, "PE": true, "PR": true,
+	"PY": true, "SV": true, "US": true, "UY": true, "VE": true,
+}
+
+// rocketLeagueLanguages maps language subtags to Rocket League's language codes.
+var rocketLeagueLanguages = map[string]string{
+	"en": "INT",
+	"de": "DEU",
+	"fr": "FRA",
+	"es": "ESN",
+	"it": "ITA",
+	"ja": "JPN",
+	"ko": "KOR",
+	"pl": "POL",
+	"pt": "PTB",
+	"ru": "RUS",
+	"tr": "TUR",
+}
+
+// epicLaunchArgs returns the arguments the Epic Games Launcher passes to Rocket League,
+// in the same order. Values are unquoted; see windowsCommandLine for how they are
+// rendered on the command line.
+func epicLaunchArgs(creds LaunchCredentials, locale, sandboxID, deploymentID string) []string {
+	args := []string{
+		"-AUTH_LOGIN=unused",
+		"-AUTH_PASSWORD=" + creds.ExchangeCode,
+		"-AUTH_TYPE=exchangecode",
+		"-epicapp=" + rocketLeagueAppName,
+		"-epicenv=Prod",
+		"-EpicPortal",
+		"-epicusername=" + creds.DisplayName,
+		"-epicuserid=" + creds.AccountID,
+		"-epiclocale=" + localeLanguage(locale),
+	}
+	if sandboxID != "" {
+		args = append(args, "-epicsandboxid="+sandboxID)
+	}
+	if deploymentID != "" {
+		args = append(args, "-epicdeploymentid="+deploymentID)
+	}
+	return args
+}
+
+// normalizeLocale turns POSIX and Windows locale names ("de_DE.UTF-8", "de-DE")
+// into a BCP 47 tag ("de-DE"), defaulting to "en-US".
+func normalizeLocale(locale string) string {
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	locale = strings.ReplaceAll(locale, "_", "-")
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return "en-US"
+	}
+	return locale
+}
+
+// localeLanguage returns the language subtag of a locale, e.g. "de" for "de-DE".
+func localeLanguage(locale string) string {
+	lang, _, _ := strings.Cut(normalizeLocale(locale), "-")
+	return strings.ToLower(lang)
+}
+
+// rocketLeagueLanguage maps a locale to Rocket League's -language code.
+// It returns "" for languages the game has no code for.
+func rocketLeagueLanguage(locale string) string {
+	lang, region, _ := strings.Cut(normalizeLocale(locale), "-")
+	lang = strings.ToLower(lang)
+	if lang == "es" && latinAmericanRegions[strings.ToUpper(region)] {
+		return "ESM"
+	}
+	return rocketLeagueLanguages[lang]
+}
+
+// languageArg returns the -language argument for the configured language setting.
+func languageArg(setting, locale string) string {
+	var code string
+	switch strings.ToLower(setting) {
+	case languageOff:
+		return ""
+	case "", languageAuto:
+		code = rocketLeagueLanguage(locale)
+	default:
+		code = strings.ToUpper(setting)
+	}
+	if code == "" {
+		return ""
+	}
+	return "-language=" + code
+}
+
+// windowsCommandLine renders arguments the way the Epic Games Launcher does:
+// -key=value arguments quote only the value when it is empty or contains spaces
+// or quotes (-epicusername="Some Name"), and other arguments with spaces are
+// quoted whole.
+func windowsCommandLine(args []string) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		key, value, hasValue := strings.Cut(arg, "=")
+		switch {
+		case i > 0 && strings.HasPrefix(arg, "-") && hasValue && !strings.ContainsAny(key, " \t\"") && needsQuoting(value):
+			parts[i] = key + "=" + quoteWindowsArg(value)
+		case arg == "" || strings.ContainsAny(arg, " \t\""):
+			parts[i] = quoteWindowsArg(arg)
+		default:
+			parts[i] = arg
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func needsQuoting(value string) bool {
+	return value == "" || strings.ContainsAny(value, " \t\"")
+}
+
+// quoteWindowsArg quotes s following the rules CommandLineToArgvW undoes: quotes are
+// escaped with a backslash, and backslashes only need doubling before a quote.
+func quoteWindowsArg(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	slashes := 0
+	for _, c := range s {
+		switch c {
+		case '\\':
+			slashes++
+		case '"':
+			b.WriteString(strings.Repeat(`\`, slashes+1))
+			slashes = 0
+		default:
+			slashes = 0
+		}
+		b.WriteRune(c)
+	}
+	b.WriteString(strings.Repeat(`\`, slashes))
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,123 @@
+// eglargs_test.go
+package main
+
+import "testing"
+
+const (
+	testExe       = `C:\Program Files\Epic Games\rocketleague\Binaries\Win64\RocketLeague.exe`
+	testSandboxID = "9773aa1aa54f4f7b80e44bef04986cea"
+)
+
+// TestEpicLaunchArgs compares the rendered command line with ones recorded from the
+// Epic Games Launcher (exchange codes and account IDs replaced).
+func TestEpicLaunchArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		creds        LaunchCredentials
+		locale       string
+		sandboxID    string
+		deploymentID string
+		want         string
+	}{
+		{
+			name:      "plain name",
+			creds:     LaunchCredentials{ExchangeCode: "0123456789abcdef0123456789abcdef", AccountID: "a1b2c3d4e5f60718293a4b5c6d7e8f90", DisplayName: "Octane"},
+			locale:    "en_US.UTF-8",
+			sandboxID: testSandboxID,
+			want: `"C:\Program Files\Epic Games\rocketleague\Binaries\Win64\RocketLeague.exe" -AUTH_LOGIN=unused -AUTH_PASSWORD=0123456789abcdef0123456789abcdef ` +
+				`-AUTH_TYPE=exchangecode -epicapp=Sugar -epicenv=Prod -EpicPortal -epicusername=Octane -epicuserid=a1b2c3d4e5f60718293a4b5c6d7e8f90 ` +
+				`-epiclocale=en -epicsandboxid=9773aa1aa54f4f7b80e44bef04986cea`,
+		},
+		{
+			name:         "name with spaces and deployment",
+			creds:        LaunchCredentials{ExchangeCode: "fedcba9876543210fedcba9876543210", AccountID: "0f1e2d3c4b5a69788796a5b4c3d2e1f0", DisplayName: "Flip Reset"},
+			locale:       "de-DE",
+			sandboxID:    testSandboxID,
+			deploymentID: "da32ae9c12ae40e8a112c52e1f17f3ba",
+			want: `"C:\Program Files\Epic Games\rocketleague\Binaries\Win64\RocketLeague.exe" -AUTH_LOGIN=unused -AUTH_PASSWORD=fedcba9876543210fedcba9876543210 ` +
+				`-AUTH_TYPE=exchangecode -epicapp=Sugar -epicenv=Prod -EpicPortal -epicusername="Flip Reset" -epicuserid=0f1e2d3c4b5a69788796a5b4c3d2e1f0 ` +
+				`-epiclocale=de -epicsandboxid=9773aa1aa54f4f7b80e44bef04986cea -epicdeploymentid=da32ae9c12ae40e8a112c52e1f17f3ba`,
+		},
+		{
+			name:   "name with a quote, no install metadata",
+			creds:  LaunchCredentials{ExchangeCode: "00112233445566778899aabbccddeeff", AccountID: "ffeeddccbbaa99887766554433221100", DisplayName: `The "Kid"`},
+			locale: "C",
+			want: `"C:\Program Files\Epic Games\rocketleague\Binaries\Win64\RocketLeague.exe" -AUTH_LOGIN=unused -AUTH_PASSWORD=00112233445566778899aabbccddeeff ` +
+				`-AUTH_TYPE=exchangecode -epicapp=Sugar -epicenv=Prod -EpicPortal -epicusername="The \"Kid\"" -epicuserid=ffeeddccbbaa99887766554433221100 ` +
+				`-epiclocale=en`,
+		},
+	}
+	for _, tt := range tests {
+		args := epicLaunchArgs(tt.creds, tt.locale, tt.sandboxID, tt.deploymentID)
+		if got := windowsCommandLine(append([]string{testExe}, args...)); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLanguageArg(t *testing.T) {
+	tests := []struct {
+		setting, locale, want string
+	}{
+		{"", "en_US.UTF-8", "-language=INT"},
+		{"auto", "de_DE.UTF-8", "-language=DEU"},
+		{"auto", "fr-CA", "-language=FRA"},
+		{"auto", "es_ES.UTF-8", "-language=ESN"},
+		{"auto", "es_MX.UTF-8", "-language=ESM"},
+		{"auto", "es-419", "-language=ESM"},
+		{"auto", "pt_BR.UTF-8", "-language=PTB"},
+		{"auto", "ja_JP.UTF-8", "-language=JPN"},
+		{"auto", "C", "-language=INT"},
+		{"auto", "nl_NL.UTF-8", ""}, // Rocket League has no Dutch localisation.
+		{"off", "de_DE.UTF-8", ""},
+		{"OFF", "de_DE.UTF-8", ""},
+		{"kor", "en_US.UTF-8", "-language=KOR"},
+	}
+	for _, tt := range tests {
+		if got := languageArg(tt.setting, tt.locale); got != tt.want {
+			t.Errorf("languageArg(%q, %q) = %q, want %q", tt.setting, tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestWindowsCommandLine(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{`C:\Games\RocketLeague.exe`, "-nomovie"}, `C:\Games\RocketLeague.exe -nomovie`},
+		{[]string{testExe, "-epicusername="}, `"` + testExe + `" -epicusername=""`},
+		{[]string{"game.exe", "-epicusername=A B"}, `game.exe -epicusername="A B"`},
+		{[]string{"game.exe", "two words"}, `game.exe "two words"`},
+		{[]string{"game.exe", ""}, `game.exe ""`},
+		{[]string{"game.exe", "-key with space=value x"}, `game.exe "-key with space=value x"`},
+		{[]string{"game.exe", "not-a-flag=a b"}, `game.exe "not-a-flag=a b"`},
+		{[]string{"game.exe", `-path=C:\My Games\`}, `game.exe -path="C:\My Games\\"`},
+	}
+	for _, tt := range tests {
+		if got := windowsCommandLine(tt.args); got != tt.want {
+			t.Errorf("windowsCommandLine(%q) = %s, want %s", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteWindowsArg(t *testing.T) {
+	tests := []struct {
+		arg, want string
+	}{
+		{"", `""`},
+		{"plain", `"plain"`},
+		{"two words", `"two words"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\Program Files\`, `"C:\Program Files\\"`},
+		{`C:\a\b`, `"C:\a\b"`},
+		{`a\"b`, `"a\\\"b"`},
+		{`a\\"b`, `"a\\\\\"b"`},
+		{`trailing\\`, `"trailing\\\\"`},
+	}
+	for _, tt := range tests {
+		if got := quoteWindowsArg(tt.arg); got != tt.want {
+			t.Errorf("quoteWindowsArg(%s) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+// folders_unix.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// documentsDir returns the user's Documents folder.
+func documentsDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, "Documents")
+}
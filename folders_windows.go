@@ -0,0 +1,15 @@
+//go:build windows
+
+// folders_windows.go
+package main
+
+import "golang.org/x/sys/windows"
+
+// documentsDir returns the user's Documents folder, which may be redirected (e.g. to OneDrive).
+func documentsDir() string {
+	dir, err := windows.KnownFolderPath(windows.FOLDERID_Documents, 0)
+	if err != nil {
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,213 @@
+// gamelog.go
+package main
+
+import (
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const launchLogFileName = "Launch.log"
+
+// rocketLeagueLogDir returns the folder Rocket League writes Launch.log to:
+// Documents/My Games/Rocket League/TAGame/Logs, inside the runner's Wine prefix
+// when the game runs through one. It returns "" if the folder can't be determined.
+func rocketLeagueLogDir(cfg Config) string {
+	docs := documentsDir()
+	if needsRunner(cfg.RocketLeaguePath) {
+		docs = prefixDocumentsDir(cfg.Runner)
+	}
+	if docs == "" {
+		return ""
+	}
+	return filepath.Join(docs, "My Games", "Rocket League", "TAGame", "Logs")
+}
+
+// launchLogPath returns the path to the game's Launch.log, or "" if unknown.
+func launchLogPath(cfg Config) string {
+	dir := rocketLeagueLogDir(cfg)
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, launchLogFileName)
+}
+
+// prefixDocumentsDir finds the Documents folder of the Wine user inside the runner's prefix.
+// Proton always uses "steamuser"; plain Wine uses the Linux user name.
+func prefixDocumentsDir(r RunnerConfig) string {
+	prefix := r.winePrefix()
+	if prefix == "" && strings.ToLower(r.Type) == runnerWine {
+		if home, err := os.UserHomeDir(); err == nil {
+			prefix = filepath.Join(home, ".wine")
+		}
+	}
+	if prefix == "" {
+		return ""
+	}
+
+	users := filepath.Join(prefix, "drive_c", "users")
+	for _, user := range []string{"steamuser", os.Getenv("USER")} {
+		if user == "" {
+			continue
+		}
+		if docs := filepath.Join(users, user, "Documents"); fileExists(docs) {
+			return docs
+		}
+	}
+	// Fall back to any user that already has Rocket League's folder.
+	if matches, _ := filepath.Glob(filepath.Join(users, "*", "Documents", "My Games", "Rocket League")); len(matches) > 0 {
+		return filepath.Dir(filepath.Dir(matches[0]))
+	}
+	return ""
+}
+
+// --- Launch.log Diagnostics ---
+
+const (
+	defaultLaunchLogWatch = 300 // seconds
+	launchLogExcerptLines = 30
+	launchLogPollInterval = time.Second
+)
+
+// logSignature is a known failure that shows up in Launch.log.
+type logSignature struct {
+	pattern *regexp.Regexp
+	title   string
+	message string
+}
+
+// eacMention matches Easy Anti-Cheat by name, or as "EAC" in capitals and on its
+// own, so that words like "Beacon" or "reached" don't count.
+const eacMention = `((?i:\beasy ?anti-?cheat)|\bEAC\b)`
+
+// launchLogSignatures are checked against every new Launch.log line, in order.
+var launchLogSignatures = []logSignature{
+	{
+		pattern: regexp.MustCompile(`(?i)exchange_code_not_found|invalid_exchange_code|AUTH_TYPE.*(rejected|invalid)`),
+		title:   "Epic Rejected the Exchange Code",
+		message: "Epic rejected the login code Slipstream passed to the game, so you are not logged in.\n\n" +
+			"This usually means the code expired before the game used it. Try launching again. " +
+			"If it keeps happening, delete 'epic_token' from 'config.json' to log in from scratch.",
+	},
+	{
+		pattern: regexp.MustCompile(eacMention + `(?i:.*not (installed|found))`),
+		title:   "Easy Anti-Cheat Not Installed",
+		message: "Easy Anti-Cheat is not installed for Rocket League, so online play won't work.\n\n" +
+			"Run the EasyAntiCheat setup from the game's 'EasyAntiCheat' folder, or verify the game in your game manager. " +
+			"To play offline without EAC, add -noeac to your launch options.",
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)version ?mismatch`),
+		title:   "Game Version Mismatch",
+		message: "Rocket League reported a version mismatch: the game is out of date.\n\n" +
+			"Update the game with the Epic Games Launcher, Heroic, or your launcher of choice, then launch again.",
+	},
+	{
+		pattern: regexp.MustCompile(eacMention + `(?i:.*(error|failed|violation))`),
+		title:   "Easy Anti-Cheat Error",
+		message: "Easy Anti-Cheat reported an error while starting Rocket League.\n\n" +
+			"Verifying the game files (Slipstream verify) or reinstalling EAC from the game's 'EasyAntiCheat' folder usually fixes this.",
+	},
+}
+
+// launchLogWatcher follows Launch.log from a given offset and reports known failures.
+type launchLogWatcher struct {
+	path     string
+	pos      logPosition
+	partial  string
+	recent   []string
+	reported map[*logSignature]bool
+}
+
+// watchLaunchLog follows Launch.log for the first part of the session and reports any
+// known failure with a dialog, copying the surrounding lines into slipstream.log.
+// It stops once the window has passed or the game has exited, and closes the
+// returned channel when done.
+func watchLaunchLog(path string, start logPosition, window time.Duration, gameDone <-chan struct{}) chan struct{} {
+	done := make(chan struct{})
+	w := &launchLogWatcher{path: path, pos: start, reported: map[*logSignature]bool{}}
+	go func() {
+		defer close(done)
+		slog.Debug("Watching Launch.log for problems", "path", path, "window", window)
+		ticker := time.NewTicker(launchLogPollInterval)
+		defer ticker.Stop()
+		deadline := time.After(window)
+		for {
+			select {
+			case <-ticker.C:
+				w.poll()
+			case <-deadline:
+				w.poll()
+				return
+			case <-gameDone:
+				// Give the game a moment to flush its last lines, which often explain the exit.
+				time.Sleep(launchLogPollInterval)
+				w.poll()
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// poll reads any new lines. A Launch.log that isn't the file read so far has been
+// recreated by a new game start, so it is read from the beginning.
+func (w *launchLogWatcher) poll() {
+	f, err := os.Open(w.path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return
+	}
+	offset := w.pos.offsetIn(info)
+	if offset != w.pos.offset {
+		w.partial = ""
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return
+	}
+	w.pos = logPosition{file: info, offset: offset + int64(len(data))}
+
+	lines := strings.Split(w.partial+string(data), "\n")
+	w.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		w.handleLine(strings.TrimRight(line, "\r"))
+	}
+}
+
+func (w *launchLogWatcher) handleLine(line string) {
+	w.recent = append(w.recent, line)
+	if len(w.recent) > launchLogExcerptLines {
+		w.recent = w.recent[len(w.recent)-launchLogExcerptLines:]
+	}
+	for i := range launchLogSignatures {
+		sig := &launchLogSignatures[i]
+		if w.reported[sig] || !sig.pattern.MatchString(line) {
+			continue
+		}
+		w.reported[sig] = true
+		log.Printf("Launch.log shows a problem: %s\n--- Launch.log excerpt ---\n%s\n--- end of excerpt ---",
+			sig.title, strings.Join(w.recent, "\n"))
+		showError(sig.title, sig.message)
+		break
+	}
+}
+
+func launchLogWatchWindow(cfg Config) time.Duration {
+	if cfg.LaunchLogWatch == 0 {
+		return defaultLaunchLogWatch * time.Second
+	}
+	return time.Duration(cfg.LaunchLogWatch) * time.Second
+}
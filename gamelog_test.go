@@ -0,0 +1,74 @@
+// gamelog_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// matchLaunchLog returns the title of the first signature matching line, or "".
+func matchLaunchLog(line string) string {
+	for _, sig := range launchLogSignatures {
+		if sig.pattern.MatchString(line) {
+			return sig.title
+		}
+	}
+	return ""
+}
+
+func TestLaunchLogSignatures(t *testing.T) {
+	tests := []struct {
+		line, want string
+	}{
+		{"[0012.34] Log: EasyAntiCheat is not installed", "Easy Anti-Cheat Not Installed"},
+		{"[0012.34] Log: EAC: service not found", "Easy Anti-Cheat Not Installed"},
+		{"[0012.34] Log: Easy Anti-Cheat initialization failed", "Easy Anti-Cheat Error"},
+		{"[0012.34] EOS: EAC client integrity violation", "Easy Anti-Cheat Error"},
+		{"[0012.34] Log: EasyAntiCheat_EOS failed to start", "Easy Anti-Cheat Error"},
+		{"[0012.34] AUTH: exchange_code_not_found", "Epic Rejected the Exchange Code"},
+		{"[0012.34] Log: Version mismatch with server", "Game Version Mismatch"},
+		// Ordinary words containing "eac" must not be mistaken for Easy Anti-Cheat.
+		{"[0103.55] OnlineBeacon: PartyBeacon connection failed", ""},
+		{"[0103.55] Log: Beacon host not found", ""},
+		{"[0211.02] Log: Timeout reached, request failed", ""},
+		{"[0211.02] Log: eac_config.ini error", ""},
+		{"[0000.01] Log: Loading EAC settings", ""},
+	}
+	for _, tt := range tests {
+		if got := matchLaunchLog(tt.line); got != tt.want {
+			t.Errorf("%q matched %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+// TestLaunchLogRotated checks that a Launch.log recreated by a new game start is read
+// from the beginning, even when it has already grown past the old read position.
+func TestLaunchLogRotated(t *testing.T) {
+	dialogs := useScriptedUI(t, "", "")
+	dir := t.TempDir()
+	path := filepath.Join(dir, launchLogFileName)
+	if err := os.WriteFile(path, []byte(strings.Repeat("[0001.00] Log: previous session\n", 5)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w := &launchLogWatcher{path: path, pos: logEnd(path), reported: map[*logSignature]bool{}}
+	w.poll()
+
+	if err := os.Rename(path, filepath.Join(dir, "Launch-backup.log")); err != nil {
+		t.Fatal(err)
+	}
+	rotated := "[0000.10] Log: EasyAntiCheat is not installed\n" + strings.Repeat("[0000.20] Log: loading\n", 10)
+	if err := os.WriteFile(path, []byte(rotated), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w.poll()
+
+	if want := []string{"Easy Anti-Cheat Not Installed"}; !slices.Equal(dialogs.Shown, want) {
+		t.Errorf("dialogs = %q, want %q", dialogs.Shown, want)
+	}
+	if w.pos.offset != int64(len(rotated)) {
+		t.Errorf("read up to %d, want %d", w.pos.offset, len(rotated))
+	}
+}
@@ -0,0 +1,50 @@
+// helpers_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useTempInstall points the executable path at a Slipstream binary in a new temporary
+// folder, so the config, lock and log files of the test live there. It returns the
+// path of the binary.
+func useTempInstall(t *testing.T) string {
+	t.Helper()
+	exe := filepath.Join(t.TempDir(), "Slipstream")
+	if err := os.WriteFile(exe, []byte("current version"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := executablePath
+	executablePath = func() (string, error) { return exe, nil }
+	t.Cleanup(func() { executablePath = old })
+	return exe
+}
+
+// useScriptedUI answers the test's dialogs from answers.
+func useScriptedUI(t *testing.T, answers ...string) *scriptedUI {
+	t.Helper()
+	s := newScriptedUI(answers...)
+	old := ui
+	ui = newLockedUI(s)
+	t.Cleanup(func() { ui = old })
+	return s
+}
+
+// writeTestConfig saves cfg as the config file of the temporary install.
+func writeTestConfig(t *testing.T, cfg Config) {
+	t.Helper()
+	if err := saveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,140 @@
+// hooks.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Hook stages, also passed to hooks as SLIPSTREAM_HOOK_STAGE.
+	hookPreLaunch = "pre-launch"
+	hookPostStart = "post-start"
+	hookPostExit  = "post-exit"
+
+	// Failure policies for Hook.OnFailure.
+	hookFailureWarn  = "warn"
+	hookFailureAbort = "abort"
+
+	defaultHookTimeout = 30 // seconds
+
+	// hookOutputDelay is how long a hook's output is still read after it exits.
+	hookOutputDelay = 2 * time.Second
+)
+
+// Hook is a user command run at a fixed point of the launch lifecycle.
+type Hook struct {
+	Name       string   `json:"name,omitempty"`
+	Command    string   `json:"command"`
+	Args       []string `json:"args,omitempty"`
+	WorkingDir string   `json:"working_dir,omitempty"`
+	Timeout    int      `json:"timeout,omitempty"`    // Seconds, defaults to 30
+	OnFailure  string   `json:"on_failure,omitempty"` // "warn" (default) or "abort"
+}
+
+func (h Hook) displayName() string {
+	if h.Name != "" {
+		return h.Name
+	}
+	return h.Command
+}
+
+// hookContext describes the launch to the hooks through environment variables.
+type hookContext struct {
+	Profile   string
+	AccountID string
+	EAC       bool
+	GamePath  string
+	GamePID   int
+	ExitCode  *int // Only set for post-exit hooks.
+}
+
+func (c hookContext) environ(stage string) []string {
+	eac := "0"
+	if c.EAC {
+		eac = "1"
+	}
+	env := append(os.Environ(),
+		"SLIPSTREAM_HOOK_STAGE="+stage,
+		"SLIPSTREAM_PROFILE="+c.Profile,
+		"SLIPSTREAM_ACCOUNT_ID="+c.AccountID,
+		"SLIPSTREAM_EAC="+eac,
+		"SLIPSTREAM_GAME_PATH="+c.GamePath,
+	)
+	if c.GamePID > 0 {
+		env = append(env, "SLIPSTREAM_GAME_PID="+strconv.Itoa(c.GamePID))
+	}
+	if c.ExitCode != nil {
+		env = append(env, "SLIPSTREAM_EXIT_CODE="+strconv.Itoa(*c.ExitCode))
+	}
+	return env
+}
+
+// runHooks runs the hooks for a stage in order. A failing hook only stops the
+// sequence, and returns an error, if its failure policy is "abort".
+func runHooks(stage string, hooks []Hook, ctx hookContext) error {
+	for _, h := range hooks {
+		if strings.TrimSpace(h.Command) == "" {
+			log.Printf("Warning: skipping %s hook without a command.", stage)
+			continue
+		}
+		err := runHook(stage, h, ctx)
+		if err == nil {
+			continue
+		}
+		if strings.EqualFold(h.OnFailure, hookFailureAbort) {
+			return fmt.Errorf("%s hook '%s' failed: %w", stage, h.displayName(), err)
+		}
+		log.Printf("Warning: %s hook '%s' failed: %v", stage, h.displayName(), err)
+	}
+	return nil
+}
+
+// runPostExitHooks runs the post-exit hooks once the game has stopped.
+func (s *gameSession) runPostExitHooks(hooks []Hook) {
+	ctx := s.hookCtx
+	if state := s.currentGame().exitState(); state != nil {
+		code := state.ExitCode()
+		ctx.ExitCode = &code
+	}
+	if err := runHooks(hookPostExit, hooks, ctx); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+func runHook(stage string, h Hook, ctx hookContext) error {
+	timeout := time.Duration(h.Timeout) * time.Second
+	if h.Timeout <= 0 {
+		timeout = defaultHookTimeout * time.Second
+	}
+	runCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	log.Printf("Running %s hook '%s'...", stage, h.displayName())
+	cmd := exec.CommandContext(runCtx, h.Command, h.Args...)
+	cmd.Dir = h.WorkingDir
+	cmd.Env = ctx.environ(stage)
+	// A hook may leave a background program running (e.g. a recorder) that still
+	// holds the output pipe; stop reading shortly after the hook itself exits.
+	cmd.WaitDelay = hookOutputDelay
+	output, err := cmd.CombinedOutput()
+	if out := strings.TrimSpace(string(output)); out != "" {
+		slog.Debug("Hook output", "hook", h.displayName(), "output", out)
+	}
+	if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %v", timeout)
+	}
+	if errors.Is(err, exec.ErrWaitDelay) {
+		slog.Debug("Hook left a process running with its output open", "hook", h.displayName())
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,249 @@
+// httptrace.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const debugHTTPFlag = "--debug-http"
+
+// The trace is written in the HTTP Archive (HAR 1.2) format, so it can be opened in
+// browser dev tools and replayed by HAR-aware tools. Only the fields Slipstream can
+// fill in are included.
+type harLog struct {
+	Log struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"` // Milliseconds
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"` // Transport error, if any
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// httpRecorder is an http.RoundTripper that records every request and response,
+// with secrets masked, to a HAR file. The file is rewritten after each request, so
+// the trace is complete even if Slipstream exits right after a failed login.
+type httpRecorder struct {
+	mu        sync.Mutex
+	path      string
+	har       harLog
+	transport http.RoundTripper
+}
+
+var (
+	httpTraceOnce sync.Once
+	httpTrace     *httpRecorder
+)
+
+// httpTracer returns the shared recorder if --debug-http was given, or nil.
+func httpTracer() *httpRecorder {
+	httpTraceOnce.Do(func() {
+		if !hasFlag(os.Args[1:], debugHTTPFlag) {
+			return
+		}
+		httpTrace = newHTTPRecorder(filepath.Join(getExecutableDir(), "slipstream-http-"+time.Now().Format("2006-01-02_15-04-05")+".har"))
+		log.Printf("Recording HTTP requests to %s", httpTrace.path)
+	})
+	return httpTrace
+}
+
+// newHTTPRecorder returns a recorder that sends requests through the default
+// transport and writes them to path.
+func newHTTPRecorder(path string) *httpRecorder {
+	r := &httpRecorder{path: path, transport: http.DefaultTransport}
+	r.har.Log.Version = "1.2"
+	r.har.Log.Creator = harCreator{Name: "Slipstream", Version: currentVersion}
+	r.har.Log.Entries = []harEntry{}
+	return r
+}
+
+// RoundTrip records the exchange. The body is read into memory so it can be recorded
+// too, and sent on a copy of the request, since a RoundTripper mustn't change the caller's.
+func (r *httpRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	start := time.Now()
+	resp, err := r.transport.RoundTrip(req)
+	wait := time.Since(start)
+
+	entry := harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         redact(req.URL.String()),
+			HTTPVersion: req.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(req.Header),
+			QueryString: harQuery(req),
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: harResponse{Cookies: []harNameValue{}, Headers: []harNameValue{}, HeadersSize: -1, BodySize: -1},
+	}
+	if reqBody != nil {
+		entry.Request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: redact(string(reqBody))}
+	}
+	if err != nil {
+		entry.Comment = redact(err.Error())
+		entry.Time = millis(wait)
+		entry.Timings = harTimings{Send: 0, Wait: millis(wait), Receive: 0}
+		r.add(entry)
+		return nil, err
+	}
+
+	respBody, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	receive := time.Since(start) - wait
+
+	entry.Response.Status = resp.StatusCode
+	entry.Response.StatusText = http.StatusText(resp.StatusCode)
+	entry.Response.HTTPVersion = resp.Proto
+	entry.Response.Headers = harHeaders(resp.Header)
+	entry.Response.RedirectURL = redact(resp.Header.Get("Location"))
+	entry.Response.BodySize = len(respBody)
+	entry.Response.Content = harContent{Size: len(respBody), MimeType: resp.Header.Get("Content-Type"), Text: redact(string(respBody))}
+	if readErr != nil {
+		entry.Comment = redact(readErr.Error())
+	}
+	entry.Time = millis(wait + receive)
+	entry.Timings = harTimings{Send: 0, Wait: millis(wait), Receive: millis(receive)}
+	r.add(entry)
+	if readErr != nil {
+		return nil, readErr
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+// add appends an entry and rewrites the trace file.
+func (r *httpRecorder) add(entry harEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.har.Log.Entries = append(r.har.Log.Entries, entry)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // Keep "<redacted>" and "&" readable.
+	enc.SetIndent("", "  ")
+	err := enc.Encode(r.har)
+	if err == nil {
+		err = os.WriteFile(r.path, buf.Bytes(), 0644)
+	}
+	if err != nil {
+		log.Printf("Warning: could not write HTTP trace to %s: %v", r.path, err)
+	}
+}
+
+func harHeaders(h http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for _, name := range sortedKeys(h) {
+		for _, v := range h[name] {
+			if strings.EqualFold(name, "Authorization") || strings.EqualFold(name, "Cookie") || strings.EqualFold(name, "Set-Cookie") {
+				v = redactedValue
+			}
+			headers = append(headers, harNameValue{Name: name, Value: redact(v)})
+		}
+	}
+	return headers
+}
+
+func harQuery(req *http.Request) []harNameValue {
+	query := []harNameValue{}
+	values := req.URL.Query()
+	for _, name := range sortedKeys(values) {
+		for _, v := range values[name] {
+			query = append(query, harNameValue{Name: name, Value: redact(name + "=" + v)[len(name)+1:]})
+		}
+	}
+	return query
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// hasFlag reports whether flag was given on the command line.
+func hasFlag(args []string, flag string) bool {
+	for _, arg := range args {
+		if strings.EqualFold(arg, flag) {
+			return true
+		}
+	}
+	return false
+}
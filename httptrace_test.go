@@ -0,0 +1,168 @@
+// httptrace_test.go
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// replayHAR serves the responses of a recorded trace, in order, from a local server.
+// Each request must match the method and path of the next recorded one; bodies and
+// headers aren't compared, since the recording has its secrets masked.
+func replayHAR(t *testing.T, path string) *httptest.Server {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var har harLog
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("%s is not a HAR file: %v", path, err)
+	}
+
+	var mu sync.Mutex
+	entries := har.Log.Entries
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(entries) == 0 {
+			t.Errorf("unexpected request %s %s: the recording has no requests left", r.Method, r.URL.Path)
+			http.Error(w, "no recorded response left", http.StatusInternalServerError)
+			return
+		}
+		entry := entries[0]
+		entries = entries[1:]
+		recorded, err := url.Parse(entry.Request.URL)
+		if err != nil || entry.Request.Method != r.Method || recorded.Path != r.URL.Path {
+			t.Errorf("request %s %s, but %s %s was recorded next", r.Method, r.URL.Path, entry.Request.Method, entry.Request.URL)
+			http.Error(w, "request doesn't match the recording", http.StatusInternalServerError)
+			return
+		}
+		for _, h := range entry.Response.Headers {
+			w.Header().Add(h.Name, h.Value)
+		}
+		w.Header().Del("Content-Length")
+		w.WriteHeader(entry.Response.Status)
+		w.Write([]byte(entry.Response.Content.Text))
+	}))
+	t.Cleanup(func() {
+		server.Close()
+		if len(entries) > 0 {
+			t.Errorf("%d recorded requests were not replayed", len(entries))
+		}
+	})
+	return server
+}
+
+// TestRecordAndReplay records a login against a fake Epic account service, then
+// replays the recording from a local server instead.
+func TestRecordAndReplay(t *testing.T) {
+	useTempInstall(t)
+	useScriptedUI(t)
+	epic := fakeEpic{
+		refreshToken: "recordedRefreshTokenValue",
+		accessToken:  "recordedAccessTokenValue",
+		exchangeCode: "0a1b2c3d4e5f60718293a4b5c6d7e8f9",
+	}
+	a := epic.serve(t)
+	path := filepath.Join(t.TempDir(), "trace.har")
+	a.client.Transport = newHTTPRecorder(path)
+
+	if _, _, err := a.GetLaunchCredentials(epic.refreshToken); err != nil {
+		t.Fatal(err)
+	}
+	trace := readTestFile(t, path)
+	for _, secret := range []string{epic.refreshToken, epic.accessToken, epic.exchangeCode, epicLauncherAuth} {
+		if strings.Contains(trace, secret) {
+			t.Errorf("%q appears in the recording:\n%s", secret, trace)
+		}
+	}
+	if !strings.Contains(trace, "Flip Reset") {
+		t.Errorf("the response body was not recorded:\n%s", trace)
+	}
+
+	replay := NewAuthenticator()
+	replay.apiURL = replayHAR(t, path).URL
+	creds, token, err := replay.GetLaunchCredentials("any refresh token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The recorded responses have their tokens and codes masked.
+	want := LaunchCredentials{ExchangeCode: redactedValue, AccountID: "0123456789abcdef0123456789abcdef", DisplayName: "Flip Reset", AccessToken: redactedValue}
+	if creds != want || token != redactedValue {
+		t.Errorf("replayed credentials = %+v, %q; want %+v", creds, token, want)
+	}
+}
+
+// TestRecordTruncatedResponse checks that a response body cut off by the server is
+// recorded and reported as an error, without a response.
+func TestRecordTruncatedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte(`{"access_token":`))
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "trace.har")
+	client := &http.Client{Transport: newHTTPRecorder(path)}
+
+	resp, err := client.Get(server.URL + tokenPath)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("a truncated response was returned without an error")
+	}
+	var har harLog
+	if err := json.Unmarshal([]byte(readTestFile(t, path)), &har); err != nil {
+		t.Fatal(err)
+	}
+	if len(har.Log.Entries) != 1 || har.Log.Entries[0].Comment == "" || har.Log.Entries[0].Response.Status != http.StatusOK {
+		t.Errorf("recorded entries = %+v, want the response with the read error", har.Log.Entries)
+	}
+}
+
+// trackedBody is a request body that records whether it was closed.
+type trackedBody struct {
+	*strings.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+// TestRecorderLeavesRequestAlone checks that recording doesn't change the caller's
+// request, as the http.RoundTripper contract requires.
+func TestRecorderLeavesRequestAlone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer server.Close()
+	body := &trackedBody{Reader: strings.NewReader("grant_type=client_credentials")}
+	req, err := http.NewRequest("POST", server.URL+tokenPath, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := newHTTPRecorder(filepath.Join(t.TempDir(), "trace.har"))
+
+	resp, err := recorder.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	echoed, _ := io.ReadAll(resp.Body)
+	if string(echoed) != "grant_type=client_credentials" {
+		t.Errorf("server received %q", echoed)
+	}
+	if req.Body != body || !body.closed {
+		t.Errorf("the caller's request body was replaced (%v) or left open (closed: %v)", req.Body != body, body.closed)
+	}
+}
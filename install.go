@@ -0,0 +1,348 @@
+// install.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// rocketLeagueAppName is Rocket League's app name in Epic's catalog and launcher metadata.
+	rocketLeagueAppName = "Sugar"
+
+	epicLauncherServiceURL = "https://launcher-public-service-prod06.ol.epicgames.com/launcher/api/public"
+	liveAssetsPath         = "/assets/Windows?label=Live"
+
+	// Policies for Config.VersionCheck.
+	versionCheckWarn  = "warn"
+	versionCheckBlock = "block"
+	versionCheckOff   = "off"
+)
+
+// installInfo is what the launcher metadata tells us about the game install.
+type installInfo struct {
+	Source           string // Where the information was read from.
+	AppName          string
+	Version          string
+	CatalogNamespace string
+	CatalogItemID    string
+	DeploymentID     string
+	ManifestPath     string // Binary Epic manifest for the installed build, if known.
+	Incomplete       bool   // An install or update is in progress or was interrupted.
+}
+
+// egsItemManifest is the subset of an Epic Games Launcher .item manifest we use.
+type egsItemManifest struct {
+	AppName             string `json:"AppName"`
+	AppVersionString    string `json:"AppVersionString"`
+	InstallLocation     string `json:"InstallLocation"`
+	ManifestLocation    string `json:"ManifestLocation"`
+	CatalogNamespace    string `json:"CatalogNamespace"`
+	CatalogItemID       string `json:"CatalogItemId"`
+	DeploymentID        string `json:"DeploymentId"`
+	InstallationGUID    string `json:"InstallationGuid"`
+	IsIncompleteInstall bool   `json:"bIsIncompleteInstall"`
+}
+
+// legendaryInstall is the subset of a Legendary installed.json entry we use.
+type legendaryInstall struct {
+	AppName           string `json:"app_name"`
+	InstallPath       string `json:"install_path"`
+	Version           string `json:"version"`
+	NeedsVerification bool   `json:"needs_verification"`
+}
+
+// egstoreManCPN is the catalog info file EGL keeps in an install's .egstore directory.
+type egstoreManCPN struct {
+	AppName          string `json:"AppName"`
+	CatalogNamespace string `json:"CatalogNamespace"`
+	CatalogItemID    string `json:"CatalogItemId"`
+	DeploymentID     string `json:"DeploymentId"`
+}
+
+// gameInstallDir returns the install root from the path to the game executable,
+// which lives in <root>/Binaries/Win64.
+func gameInstallDir(rocketLeaguePath string) string {
+	dir := filepath.Dir(rocketLeaguePath)
+	if strings.EqualFold(filepath.Base(dir), "Win64") && strings.EqualFold(filepath.Base(filepath.Dir(dir)), "Binaries") {
+		return filepath.Dir(filepath.Dir(dir))
+	}
+	return dir
+}
+
+// findInstallInfo looks for launcher metadata describing the install at rocketLeaguePath.
+// Epic Games Launcher manifests are preferred, then Legendary/Heroic, then the install's
+// own .egstore directory.
+func findInstallInfo(rocketLeaguePath string) (installInfo, error) {
+	root := gameInstallDir(rocketLeaguePath)
+
+	if info, ok := findEGLInstall(root); ok {
+		return info, nil
+	}
+	if info, ok := findLegendaryInstall(root); ok {
+		return info, nil
+	}
+	if info, ok := findEgstoreInstall(root); ok {
+		return info, nil
+	}
+	return installInfo{}, fmt.Errorf("no launcher metadata found for %s", root)
+}
+
+// eglManifestDirs lists where the Epic Games Launcher keeps its .item manifests,
+// including inside the Wine prefix the game is installed in.
+func eglManifestDirs(root string) []string {
+	var dirs []string
+	if runtime.GOOS == "windows" {
+		programData := os.Getenv("ProgramData")
+		if programData == "" {
+			programData = `C:\ProgramData`
+		}
+		dirs = append(dirs, filepath.Join(programData, "Epic", "EpicGamesLauncher", "Data", "Manifests"))
+	}
+	if i := strings.Index(filepath.ToSlash(root), "/drive_c/"); i >= 0 {
+		driveC := root[:i+len("/drive_c")]
+		dirs = append(dirs, filepath.Join(driveC, "ProgramData", "Epic", "EpicGamesLauncher", "Data", "Manifests"))
+	}
+	return dirs
+}
+
+func findEGLInstall(root string) (installInfo, bool) {
+	for _, dir := range eglManifestDirs(root) {
+		items, _ := filepath.Glob(filepath.Join(dir, "*.item"))
+		for _, item := range items {
+			data, err := os.ReadFile(item)
+			if err != nil {
+				continue
+			}
+			var m egsItemManifest
+			if err := json.Unmarshal(data, &m); err != nil {
+				log.Printf("Warning: could not parse launcher manifest %s: %v", item, err)
+				continue
+			}
+			if m.AppName != rocketLeagueAppName || !samePath(m.InstallLocation, root) {
+				continue
+			}
+			info := installInfo{
+				Source:           item,
+				AppName:          m.AppName,
+				Version:          m.AppVersionString,
+				CatalogNamespace: m.CatalogNamespace,
+				CatalogItemID:    m.CatalogItemID,
+				DeploymentID:     m.DeploymentID,
+				Incomplete:       m.IsIncompleteInstall || egstoreUpdatePending(root),
+			}
+			if m.ManifestLocation != "" && m.InstallationGUID != "" {
+				info.ManifestPath = filepath.Join(m.ManifestLocation, m.InstallationGUID+".manifest")
+			}
+			return info, true
+		}
+	}
+	return installInfo{}, false
+}
+
+// legendaryConfigDirs lists the config directories of Legendary and Heroic's bundled copy.
+func legendaryConfigDirs() []string {
+	var dirs []string
+	if dir := os.Getenv("LEGENDARY_CONFIG_PATH"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if configDir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs,
+			filepath.Join(configDir, "legendary"),
+			filepath.Join(configDir, "heroic", "legendaryConfig", "legendary"),
+		)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".var", "app", "com.heroicgameslauncher.hgl", "config", "heroic", "legendaryConfig", "legendary"))
+	}
+	return dirs
+}
+
+func findLegendaryInstall(root string) (installInfo, bool) {
+	for _, dir := range legendaryConfigDirs() {
+		path := filepath.Join(dir, "installed.json")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var installed map[string]legendaryInstall
+		if err := json.Unmarshal(data, &installed); err != nil {
+			log.Printf("Warning: could not parse %s: %v", path, err)
+			continue
+		}
+		game, ok := installed[rocketLeagueAppName]
+		if !ok || !samePath(game.InstallPath, root) {
+			continue
+		}
+		return installInfo{
+			Source:       path,
+			AppName:      game.AppName,
+			Version:      game.Version,
+			ManifestPath: filepath.Join(dir, "manifests", game.AppName+".manifest"),
+			Incomplete:   game.NeedsVerification || fileExists(filepath.Join(dir, "tmp", game.AppName+".resume")),
+		}, true
+	}
+	return installInfo{}, false
+}
+
+// findEgstoreInstall reads the .egstore directory EGL writes into the install itself.
+// The version comes from the binary manifest stored next to the catalog info.
+func findEgstoreInstall(root string) (installInfo, bool) {
+	egstore := filepath.Join(root, ".egstore")
+	cpns, _ := filepath.Glob(filepath.Join(egstore, "*.mancpn"))
+	if len(cpns) == 0 {
+		return installInfo{}, false
+	}
+	data, err := os.ReadFile(cpns[0])
+	if err != nil {
+		return installInfo{}, false
+	}
+	var cpn egstoreManCPN
+	if err := json.Unmarshal(data, &cpn); err != nil {
+		log.Printf("Warning: could not parse %s: %v", cpns[0], err)
+		return installInfo{}, false
+	}
+	if cpn.AppName != rocketLeagueAppName {
+		return installInfo{}, false
+	}
+	info := installInfo{
+		Source:           cpns[0],
+		AppName:          cpn.AppName,
+		CatalogNamespace: cpn.CatalogNamespace,
+		CatalogItemID:    cpn.CatalogItemID,
+		DeploymentID:     cpn.DeploymentID,
+		Incomplete:       egstoreUpdatePending(root),
+	}
+	if manifest := strings.TrimSuffix(cpns[0], ".mancpn") + ".manifest"; fileExists(manifest) {
+		info.ManifestPath = manifest
+		if m, err := readEpicManifestFile(manifest); err == nil {
+			info.Version = m.BuildVersion
+		}
+	}
+	return info, true
+}
+
+// egstoreUpdatePending reports whether EGL has staged but not yet applied update data.
+func egstoreUpdatePending(root string) bool {
+	entries, err := os.ReadDir(filepath.Join(root, ".egstore", "Pending"))
+	return err == nil && len(entries) > 0
+}
+
+func samePath(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	clean := func(p string) string {
+		return strings.TrimRight(filepath.Clean(filepath.FromSlash(p)), `/\`)
+	}
+	return strings.EqualFold(clean(a), clean(b))
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// --- Version Check ---
+
+// launcherAsset is an entry of the launcher service's assets list.
+type launcherAsset struct {
+	AppName       string `json:"appName"`
+	LabelName     string `json:"labelName"`
+	BuildVersion  string `json:"buildVersion"`
+	CatalogItemID string `json:"catalogItemId"`
+	Namespace     string `json:"namespace"`
+}
+
+// getLatestBuildVersion asks Epic's launcher service for the current live build of an app.
+func (a *Authenticator) getLatestBuildVersion(accessToken, appName string) (string, error) {
+	var assets []launcherAsset
+	err := a.requestURL("GET", epicLauncherServiceURL+liveAssetsPath, nil, "bearer "+accessToken, &assets)
+	if err != nil {
+		return "", fmt.Errorf("assets request failed: %w", err)
+	}
+	for _, asset := range assets {
+		if asset.AppName == appName {
+			return asset.BuildVersion, nil
+		}
+	}
+	return "", fmt.Errorf("app %s not found in launcher assets", appName)
+}
+
+// buildChangelist returns the changelist number of a build version such as
+// "++Prime+Update50-CL-1234567".
+func buildChangelist(version string) (int, bool) {
+	i := strings.LastIndex(strings.ToUpper(version), "-CL-")
+	if i < 0 {
+		return 0, false
+	}
+	cl, err := strconv.Atoi(version[i+len("-CL-"):])
+	return cl, err == nil
+}
+
+// buildOlder reports whether the installed build is older than the latest one, and
+// whether the two could be compared at all.
+func buildOlder(installed, latest string) (older, known bool) {
+	have, haveOK := buildChangelist(installed)
+	want, wantOK := buildChangelist(latest)
+	if !haveOK || !wantOK {
+		return false, false
+	}
+	return have < want, true
+}
+
+// checkGameVersion compares the installed build with the live build and warns about,
+// or blocks, a launch that would end in a "version mismatch" error.
+// It returns false if the launch should not go ahead.
+func checkGameVersion(cfg Config, auth *Authenticator, accessToken string) bool {
+	policy := strings.ToLower(cfg.VersionCheck)
+	if policy == versionCheckOff {
+		return true
+	}
+
+	info, err := findInstallInfo(cfg.RocketLeaguePath)
+	if err != nil {
+		log.Printf("Skipping game version check: %v", err)
+		return true
+	}
+	log.Printf("Installed game version: %q (from %s)", info.Version, info.Source)
+
+	var problem string
+	if info.Incomplete {
+		problem = "Rocket League is in the middle of an update, or a previous update did not finish."
+	} else if info.Version != "" {
+		latest, err := auth.getLatestBuildVersion(accessToken, info.AppName)
+		if err != nil {
+			log.Printf("Skipping game version check: %v", err)
+			return true
+		}
+		log.Printf("Latest game version: %q", latest)
+		if latest != "" && latest != info.Version {
+			if older, known := buildOlder(info.Version, latest); older {
+				problem = fmt.Sprintf("Rocket League is out of date.\n\nInstalled: %s\nLatest: %s", info.Version, latest)
+			} else if known {
+				log.Printf("The installed game is newer than the live build; launching anyway.")
+			} else {
+				log.Printf("The installed and latest game versions differ but can't be compared; launching anyway.")
+			}
+		}
+	}
+	if problem == "" {
+		return true
+	}
+
+	advice := "Please update the game with the Epic Games Launcher, Heroic, or your launcher of choice. " +
+		"Online matches will fail with a \"version mismatch\" error until then."
+	if policy == versionCheckBlock {
+		showError("Game Update Required", problem+"\n\n"+advice)
+		return false
+	}
+	log.Printf("Warning: %s", problem)
+	return askQuestion(question{Title: "Game Update Required", Message: problem + "\n\n" + advice, OK: "Launch Anyway", Cancel: "Cancel", Icon: iconWarning}) == answerOK
+}
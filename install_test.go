@@ -0,0 +1,63 @@
+// install_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFindInstallInfoEGL reads the launcher manifest EGL keeps inside the Wine prefix.
+func TestFindInstallInfoEGL(t *testing.T) {
+	driveC := filepath.Join(t.TempDir(), "pfx", "drive_c")
+	root := filepath.Join(driveC, "Program Files", "Epic Games", "rocketleague")
+	manifests := filepath.Join(driveC, "ProgramData", "Epic", "EpicGamesLauncher", "Data", "Manifests")
+	for _, dir := range []string{filepath.Join(root, "Binaries", "Win64"), manifests} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	item := `{
+		"FormatVersion": 0,
+		"AppName": "Sugar",
+		"AppVersionString": "++Prime+Update50-CL-1234567",
+		"InstallLocation": "` + filepath.ToSlash(root) + `",
+		"ManifestLocation": "` + filepath.ToSlash(manifests) + `/.manifest",
+		"CatalogNamespace": "9773aa1aa54f4f7b80e44bef04986cea",
+		"CatalogItemId": "530145df28a24424923f5828cc9031a1",
+		"DeploymentId": "da32ae9c12ae40e8a112c52e1f17f3ba",
+		"InstallationGuid": "0123456789ABCDEF0123456789ABCDEF",
+		"bIsIncompleteInstall": false
+	}`
+	if err := os.WriteFile(filepath.Join(manifests, "0123456789ABCDEF0123456789ABCDEF.item"), []byte(item), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := findInstallInfo(filepath.Join(root, "Binaries", "Win64", "RocketLeague_EAC.exe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "++Prime+Update50-CL-1234567" || info.CatalogNamespace != "9773aa1aa54f4f7b80e44bef04986cea" ||
+		info.DeploymentID != "da32ae9c12ae40e8a112c52e1f17f3ba" || info.Incomplete {
+		t.Errorf("findInstallInfo = %+v", info)
+	}
+}
+
+func TestBuildOlder(t *testing.T) {
+	tests := []struct {
+		installed, latest string
+		older, known      bool
+	}{
+		{"++Prime+Update50-CL-1234567", "++Prime+Update51-CL-1240000", true, true},
+		{"++Prime+Update51-CL-1240000", "++Prime+Update50-CL-1234567", false, true},
+		{"++Prime+Update50-CL-1234567", "++prime+update50-cl-1234567", false, true},
+		// Build strings without a comparable changelist are never reported as out of date.
+		{"++Prime+Update50-CL-1234567-Hotfix", "++Prime+Update50-CL-1234567", false, false},
+		{"1.0.1234567", "++Prime+Update50-CL-1234567", false, false},
+	}
+	for _, tt := range tests {
+		if older, known := buildOlder(tt.installed, tt.latest); older != tt.older || known != tt.known {
+			t.Errorf("buildOlder(%q, %q) = %v, %v; want %v, %v", tt.installed, tt.latest, older, known, tt.older, tt.known)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// kdialog.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// kdialogUI uses KDE's kdialog program, for Plasma systems without zenity.
+type kdialogUI struct{}
+
+func (kdialogUI) Info(title, message string) {
+	runKDialog("--title", title, "--msgbox", kdialogText(message))
+}
+
+func (kdialogUI) Error(title, message string) {
+	runKDialog("--title", title, "--error", kdialogText(message))
+}
+
+// Question uses --yesno, or --yesnocancel with the "no" button as the extra button.
+// kdialog exits with 0 for yes, 1 for no and 2 for cancel.
+func (kdialogUI) Question(q question) answer {
+	kind := "--yesno"
+	if q.Icon == iconWarning || q.Icon == iconError {
+		kind = "--warningyesno"
+	}
+	args := []string{"--title", q.Title, "--yes-label", orDefault(q.OK, "OK")}
+	if q.Extra != "" {
+		kind = strings.Replace(kind, "yesno", "yesnocancel", 1)
+		args = append(args, "--no-label", q.Extra, "--cancel-label", orDefault(q.Cancel, "Cancel"))
+	} else {
+		args = append(args, "--no-label", orDefault(q.Cancel, "Cancel"))
+	}
+	_, err := runKDialog(append(args, kind, kdialogText(q.Message))...)
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return answerOK
+	case q.Extra != "" && errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return answerExtra
+	}
+	return answerCancel
+}
+
+func (kdialogUI) Entry(title, message, value string) (string, error) {
+	return kdialogResult(runKDialog("--title", title, "--inputbox", kdialogText(message), value))
+}
+
+func (kdialogUI) SelectFile(title string, filters []fileFilter) (string, error) {
+	// kdialog filters take the form "Name (pattern pattern)", one per line.
+	var lines []string
+	for _, f := range filters {
+		lines = append(lines, fmt.Sprintf("%s (%s)", f.Name, strings.Join(f.Patterns, " ")))
+	}
+	return kdialogResult(runKDialog("--title", title, "--getopenfilename", ".", strings.Join(lines, "\n")))
+}
+
+func (kdialogUI) List(title, message string, items []string) (string, error) {
+	args := []string{"--title", title, "--menu", kdialogText(message)}
+	for _, item := range items {
+		args = append(args, item, item)
+	}
+	return kdialogResult(runKDialog(args...))
+}
+
+// QRCode shows the code inside the message box, which kdialog renders as rich text.
+func (k kdialogUI) QRCode(title, message string, code *qrCode, image string) {
+	fmt.Print(code.terminalArt())
+	if image == "" {
+		k.Info(title, message)
+		return
+	}
+	runKDialog("--title", title, "--msgbox", fmt.Sprintf(`<html><p><img src="%s"></p><p>%s</p></html>`, html.EscapeString(image), kdialogHTML(message)))
+}
+
+// Progress opens a kdialog progress bar, which is controlled over D-Bus with qdbus.
+func (kdialogUI) Progress(title string) (progressDialog, error) {
+	qdbus := findProgram("qdbus", "qdbus6", "qdbus-qt5")
+	if qdbus == "" {
+		return nil, errors.New("qdbus is not installed")
+	}
+	ref, err := runKDialog("--title", title, "--progressbar", title, "100")
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(ref)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("unexpected kdialog progress reference %q", ref)
+	}
+	return &kdialogProgress{qdbus: qdbus, service: fields[0], path: fields[1]}, nil
+}
+
+type kdialogProgress struct {
+	qdbus, service, path string
+}
+
+func (p *kdialogProgress) call(args ...string) error {
+	return exec.Command(p.qdbus, append([]string{p.service, p.path}, args...)...).Run()
+}
+
+func (p *kdialogProgress) Text(text string) error { return p.call("setLabelText", text) }
+func (p *kdialogProgress) Value(v int) error      { return p.call("Set", "", "value", strconv.Itoa(v)) }
+func (p *kdialogProgress) Complete() error        { return p.Value(100) }
+func (p *kdialogProgress) Close() error           { return p.call("close") }
+
+// kdialogText marks message as rich text with its markup escaped. Qt guesses
+// whether a label holds rich text, and would swallow text like "<redacted>" as an
+// unknown tag.
+func kdialogText(message string) string {
+	return "<html>" + kdialogHTML(message) + "</html>"
+}
+
+func kdialogHTML(message string) string {
+	return strings.ReplaceAll(html.EscapeString(message), "\n", "<br>")
+}
+
+func runKDialog(args ...string) (string, error) {
+	out, err := exec.Command("kdialog", args...).Output()
+	return strings.TrimRight(string(out), "\n"), err
+}
+
+// kdialogResult reports kdialog's cancel exit status as errDialogCanceled.
+func kdialogResult(s string, err error) (string, error) {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return "", errDialogCanceled
+	}
+	return s, err
+}
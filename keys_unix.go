@@ -0,0 +1,10 @@
+//go:build !windows
+
+// keys_unix.go
+package main
+
+// shiftHeld always reports false: reading the keyboard state needs X11 or evdev
+// access, so on Linux the settings editor is opened with the settings command.
+func shiftHeld() bool {
+	return false
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+// keys_windows.go
+package main
+
+import "golang.org/x/sys/windows"
+
+const vkShift = 0x10
+
+var procGetAsyncKeyState = windows.NewLazySystemDLL("user32.dll").NewProc("GetAsyncKeyState")
+
+// shiftHeld reports whether a Shift key is held down right now.
+func shiftHeld() bool {
+	state, _, _ := procGetAsyncKeyState.Call(vkShift)
+	return state&0x8000 != 0
+}
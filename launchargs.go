@@ -0,0 +1,138 @@
+// launchargs.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+const presetFlagPrefix = "--preset="
+
+// LaunchPreset is a named set of launch arguments, selected with --preset=<name>.
+type LaunchPreset struct {
+	Args []string `json:"args,omitempty"`
+	EAC  *bool    `json:"eac,omitempty"` // false launches without Easy Anti-Cheat, like -noeac
+}
+
+// resolveLaunchArgs builds the game arguments from the configured defaults, the
+// selected preset and the command line, in that order, and decides whether to use EAC.
+// Slipstream's own flags and any login arguments are removed. Drops (!-key) are kept,
+// so they also apply to the Epic Games Launcher's arguments merged in later.
+func resolveLaunchArgs(cfg Config, cmdline []string) ([]string, bool, error) {
+	useEAC := true
+	var presetArgs, userArgs []string
+	for _, arg := range cmdline {
+		argLower := strings.ToLower(arg)
+		switch {
+		case strings.HasPrefix(argLower, presetFlagPrefix):
+			name := arg[len(presetFlagPrefix):]
+			preset, ok := findPreset(cfg.Presets, name)
+			if !ok {
+				return nil, false, fmt.Errorf("unknown launch preset %q (available: %s)", name, strings.Join(presetNames(cfg.Presets), ", "))
+			}
+			presetArgs = preset.Args
+			if preset.EAC != nil && !*preset.EAC {
+				useEAC = false
+			}
+		case strings.HasPrefix(argLower, "--config=") || argLower == dryRunFlag || argLower == verboseFlag || argLower == debugHTTPFlag:
+			// Slipstream's own flags are not passed to the game.
+		default:
+			userArgs = append(userArgs, arg)
+		}
+	}
+
+	var args []string
+	lists := [][]string{withoutLoginArgs(cfg.LaunchArgs), withoutLoginArgs(presetArgs), withoutLoginArgs(userArgs)}
+	for _, arg := range mergeArgLists(lists...) {
+		if strings.EqualFold(arg, "-noeac") {
+			useEAC = false
+			continue
+		}
+		args = append(args, arg)
+	}
+	return args, useEAC, nil
+}
+
+// mergeLaunchArgs combines argument lists so that later lists override earlier ones:
+// repeated flags appear once, the last value wins for -key=value arguments, and an
+// argument of the form !-key removes -key (with any value) added so far.
+func mergeLaunchArgs(lists ...[]string) []string {
+	var args []string
+	for _, arg := range mergeArgLists(lists...) {
+		if !strings.HasPrefix(arg, "!") {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// mergeArgLists merges like mergeLaunchArgs but keeps each !-key in the result, so
+// that merging the result after another list drops -key from that list too.
+func mergeArgLists(lists ...[]string) []string {
+	var merged []string
+	for _, list := range lists {
+		for _, arg := range list {
+			arg = strings.TrimSpace(arg)
+			if arg == "" {
+				continue
+			}
+			key := launchArgKey(arg)
+			kept := merged[:0]
+			for _, existing := range merged {
+				if launchArgKey(existing) != key {
+					kept = append(kept, existing)
+				}
+			}
+			merged = append(kept, arg)
+		}
+	}
+	return merged
+}
+
+// launchArgKey identifies an argument for merging: the part before '=', ignoring case
+// and any leading '!'.
+func launchArgKey(arg string) string {
+	key, _, _ := strings.Cut(strings.TrimPrefix(arg, "!"), "=")
+	return strings.ToLower(key)
+}
+
+// isLoginArg reports whether arg, or the argument a !-key drops, is one of the login
+// arguments Slipstream passes like the Epic Games Launcher (-AUTH_*, -epic*).
+func isLoginArg(arg string) bool {
+	key := launchArgKey(arg)
+	return strings.HasPrefix(key, "-auth_") || strings.HasPrefix(key, "-epic")
+}
+
+// withoutLoginArgs removes login arguments, which would override or drop the ones
+// Slipstream logs in with, and warns about each.
+func withoutLoginArgs(args []string) []string {
+	var kept []string
+	for _, arg := range args {
+		if isLoginArg(arg) {
+			log.Printf("Warning: ignoring launch argument %q: Slipstream sets it when it logs you in.", arg)
+			continue
+		}
+		kept = append(kept, arg)
+	}
+	return kept
+}
+
+func findPreset(presets map[string]LaunchPreset, name string) (LaunchPreset, bool) {
+	for key, preset := range presets {
+		if strings.EqualFold(key, name) {
+			return preset, true
+		}
+	}
+	return LaunchPreset{}, false
+}
+
+func presetNames(presets map[string]LaunchPreset) []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
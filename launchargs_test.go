@@ -0,0 +1,37 @@
+// launchargs_test.go
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMergeLaunchArgs(t *testing.T) {
+	got := mergeLaunchArgs([]string{"-nomovie", "-language=INT", "-high"}, []string{"-LANGUAGE=DEU", "!-high", " ", "-nomovie"})
+	if want := []string{"-LANGUAGE=DEU", "-nomovie"}; !slices.Equal(got, want) {
+		t.Errorf("mergeLaunchArgs = %q, want %q", got, want)
+	}
+}
+
+// TestResolveLaunchArgsWithEpicArgs checks that user arguments can't replace or drop
+// the login arguments, and that their drops apply to Slipstream's own arguments.
+func TestResolveLaunchArgsWithEpicArgs(t *testing.T) {
+	cfg := Config{
+		LaunchArgs: []string{"-nomovie", "-AUTH_PASSWORD=stale"},
+		Presets:    map[string]LaunchPreset{"offline": {Args: []string{"!-language", "!-epicuserid"}, EAC: new(bool)}},
+	}
+	userArgs, useEAC, err := resolveLaunchArgs(cfg, []string{"--preset=offline", "-epicsandboxid=other", "-high", dryRunFlag})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if useEAC {
+		t.Error("the preset's \"eac\": false was ignored")
+	}
+	creds := LaunchCredentials{ExchangeCode: "0123456789abcdef0123456789abcdef", AccountID: "a1b2c3d4e5f60718293a4b5c6d7e8f90", DisplayName: "Octane"}
+	epicArgs := append(epicLaunchArgs(creds, "de-DE", "9773aa1aa54f4f7b80e44bef04986cea", ""), languageArg("", "de-DE"))
+	got := mergeLaunchArgs(epicArgs, userArgs)
+	want := append(epicLaunchArgs(creds, "de-DE", "9773aa1aa54f4f7b80e44bef04986cea", ""), "-nomovie", "-high")
+	if !slices.Equal(got, want) {
+		t.Errorf("launch args = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,109 @@
+// launchenv.go
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// Process priorities for Config.Priority.
+	priorityLow         = "low"
+	priorityBelowNormal = "below_normal"
+	priorityNormal      = "normal"
+	priorityAboveNormal = "above_normal"
+	priorityHigh        = "high"
+
+	dryRunFlag = "--dry-run"
+)
+
+// applyLaunchEnvironment applies the profile's environment variables, working
+// directory, wrapper chain and priority to cmd, returning the command to start.
+// Environment values, wrapper arguments and the working directory may refer to
+// existing environment variables as ${VAR}.
+func applyLaunchEnvironment(cmd *exec.Cmd, cfg Config, exe string) (*exec.Cmd, error) {
+	if len(cfg.Env) > 0 {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		for _, key := range sortedKeys(cfg.Env) {
+			cmd.Env = append(cmd.Env, key+"="+os.ExpandEnv(cfg.Env[key]))
+		}
+	}
+
+	switch {
+	case cfg.WorkingDir != "":
+		cmd.Dir = os.ExpandEnv(cfg.WorkingDir)
+	case cmd.Dir == "":
+		cmd.Dir = filepath.Dir(exe) // The game expects to start in Binaries/Win64, as with the Epic launcher.
+	}
+
+	if len(cfg.Wrapper) > 0 {
+		wrapper := make([]string, len(cfg.Wrapper))
+		for i, arg := range cfg.Wrapper {
+			wrapper[i] = os.ExpandEnv(arg)
+		}
+		cmd = wrapCommand(cmd, wrapper)
+	}
+
+	switch strings.ToLower(cfg.Priority) {
+	case "", priorityNormal:
+		return cmd, nil
+	case priorityLow, priorityBelowNormal, priorityAboveNormal, priorityHigh:
+		return setPriority(cmd, strings.ToLower(cfg.Priority)), nil
+	default:
+		return nil, fmt.Errorf("unknown priority %q (expected low, below_normal, normal, above_normal or high)", cfg.Priority)
+	}
+}
+
+// wrapCommand returns a command that runs cmd through the given wrapper command line.
+func wrapCommand(cmd *exec.Cmd, wrapper []string) *exec.Cmd {
+	wrapped := exec.Command(wrapper[0], append(wrapper[1:], cmd.Args...)...)
+	wrapped.Env = cmd.Env
+	wrapped.Dir = cmd.Dir
+	wrapped.SysProcAttr = cmd.SysProcAttr
+	return wrapped
+}
+
+// logLaunchCommand logs the full command line with the exchange code masked, and at
+// debug level the working directory and environment overrides.
+func logLaunchCommand(prefix string, cmd *exec.Cmd, cfg Config) {
+	args := make([]string, len(cmd.Args))
+	for i, arg := range cmd.Args {
+		if strings.HasPrefix(strings.ToUpper(arg), "-AUTH_PASSWORD=") {
+			arg = arg[:len("-AUTH_PASSWORD=")] + "<redacted>"
+		}
+		if strings.ContainsAny(arg, " \t") {
+			arg = `"` + arg + `"`
+		}
+		args[i] = arg
+	}
+	log.Printf("%s command: %s", prefix, strings.Join(args, " "))
+	slog.Debug(prefix+" working directory", "dir", cmd.Dir)
+	for _, key := range sortedKeys(cfg.Env) {
+		slog.Debug(prefix+" environment", "key", key, "value", os.ExpandEnv(cfg.Env[key]))
+	}
+	if cfg.Priority != "" {
+		log.Printf("%s priority: %s", prefix, cfg.Priority)
+	}
+}
+
+// isDryRun reports whether Slipstream should only show the launch command.
+func isDryRun(args []string) bool {
+	return hasFlag(args, dryRunFlag)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
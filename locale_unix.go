@@ -0,0 +1,16 @@
+//go:build !windows
+
+// locale_unix.go
+package main
+
+import "os"
+
+// systemLocale returns the user's locale from the POSIX environment, e.g. "de_DE.UTF-8".
+func systemLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+// locale_windows.go
+package main
+
+import "golang.org/x/sys/windows"
+
+// systemLocale returns the user's preferred UI language, e.g. "de-DE".
+func systemLocale() string {
+	langs, err := windows.GetUserPreferredUILanguages(windows.MUI_LANGUAGE_NAME)
+	if err != nil || len(langs) == 0 {
+		return ""
+	}
+	return langs[0]
+}
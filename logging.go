@@ -0,0 +1,180 @@
+// logging.go
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	logFileName        = "slipstream.log"
+	verboseFlag        = "--verbose"
+	defaultLogMaxSize  = 5 // MB
+	defaultLogMaxFiles = 3
+
+	// Formats for Config.LogFormat.
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// sessionID identifies this run in every log line, so interleaved runs can be told apart.
+var sessionID = newSessionID()
+
+func newSessionID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "00000000"
+	}
+	return hex.EncodeToString(b)
+}
+
+// setupLogging sends log output, including the standard log package, through slog
+// to stdout and the rotating log file, with secrets masked. It returns a function
+// that closes the file.
+func setupLogging(cfg Config, verbose bool) func() {
+	level := parseLogLevel(cfg.LogLevel)
+	if verbose {
+		level = slog.LevelDebug
+	}
+
+	var out io.Writer = os.Stdout
+	file, err := openRotatingFile(filepath.Join(getExecutableDir(), logFileName), cfg.LogMaxSize, cfg.LogMaxFiles)
+	if err == nil {
+		out = io.MultiWriter(os.Stdout, file)
+	}
+	out = &redactingWriter{w: out}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if strings.ToLower(cfg.LogFormat) == logFormatJSON {
+		handler = slog.NewJSONHandler(out, opts)
+	} else {
+		handler = slog.NewTextHandler(out, opts)
+	}
+	logger := slog.New(handler).With("session", sessionID)
+	slog.SetDefault(logger)
+
+	// Existing log.Printf calls mark their level with a "Warning:" or "ERROR:" prefix.
+	log.SetFlags(0)
+	log.SetOutput(&levelWriter{logger: logger})
+
+	if err != nil {
+		slog.Warn("Failed to open log file", "error", err)
+		return func() {}
+	}
+	return func() { file.Close() }
+}
+
+func parseLogLevel(s string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// isVerbose reports whether debug logging was requested on the command line.
+func isVerbose(args []string) bool {
+	return hasFlag(args, verboseFlag)
+}
+
+// levelWriter turns lines from the standard log package into slog records.
+type levelWriter struct {
+	logger *slog.Logger
+}
+
+func (w *levelWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\n")
+	level := slog.LevelInfo
+	for prefix, l := range map[string]slog.Level{"Warning: ": slog.LevelWarn, "ERROR: ": slog.LevelError} {
+		if strings.HasPrefix(msg, prefix) {
+			msg, level = strings.TrimPrefix(msg, prefix), l
+		}
+	}
+	w.logger.Log(context.Background(), level, msg)
+	return len(p), nil
+}
+
+// rotatingFile is a log file that is rotated once it grows past maxSize, keeping
+// maxFiles old files as name.1 (newest) to name.N.
+type rotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	size     int64
+	maxSize  int64
+	maxFiles int
+}
+
+func openRotatingFile(path string, maxSizeMB, maxFiles int) (*rotatingFile, error) {
+	if maxSizeMB <= 0 {
+		maxSizeMB = defaultLogMaxSize
+	}
+	if maxFiles <= 0 {
+		maxFiles = defaultLogMaxFiles
+	}
+	r := &rotatingFile{path: path, maxSize: int64(maxSizeMB) << 20, maxFiles: maxFiles}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "could not rotate %s: %v\n", r.path, err)
+			r.size = 0 // Try again once another maxSize has been written.
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the old files up by one, dropping the oldest, and starts a new file.
+// If the file can't be renamed (e.g. another instance has it open on Windows), it
+// keeps writing to the current one.
+func (r *rotatingFile) rotate() error {
+	r.file.Close()
+	os.Remove(fmt.Sprintf("%s.%d", r.path, r.maxFiles))
+	for i := r.maxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	renameErr := os.Rename(r.path, r.path+".1")
+	if err := r.open(); err != nil {
+		return err
+	}
+	return renameErr
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
@@ -6,18 +6,19 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
-	"time" // Added for BakkesMod launch delay
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/ncruces/zenity"
 )
 
 // --- Constants ---
@@ -40,19 +41,54 @@ const (
 
 // Config holds all application settings.
 type Config struct {
-	RocketLeaguePath       string `json:"rocket_league_path"`
-	EpicToken              string `json:"epic_token,omitempty"`
-	BakkesModEnabled       bool   `json:"bakkesmod_enabled"` // No omitempty, so it defaults to false in JSON
-	BakkesModPath          string `json:"bakkesmod_path,omitempty"`
-	BakkesModLaunchDelay   int    `json:"bakkesmod_launch_delay,omitempty"`
-	BakkesModSetupDeclined bool   `json:"bakkesmod_setup_declined"` // No omitempty, so it defaults to false
-	LastNotifiedVersion    string `json:"last_notified_version,omitempty"`
+	RocketLeaguePath       string                  `json:"rocket_league_path"`
+	EpicToken              string                  `json:"epic_token,omitempty"`
+	BakkesModEnabled       bool                    `json:"bakkesmod_enabled,omitempty"`      // Deprecated: migrated into Companions
+	BakkesModPath          string                  `json:"bakkesmod_path,omitempty"`         // Deprecated: migrated into Companions
+	BakkesModLaunchDelay   int                     `json:"bakkesmod_launch_delay,omitempty"` // Deprecated: migrated into Companions
+	BakkesModSetupDeclined bool                    `json:"bakkesmod_setup_declined"`         // No omitempty, so it defaults to false
+	Companions             []Companion             `json:"companions,omitempty"`
+	EACCheck               string                  `json:"eac_check,omitempty"`             // "warn" (default) or "off"
+	CrashRestart           string                  `json:"crash_restart,omitempty"`         // "ask" (default), "auto" or "off"
+	MaxRestarts            int                     `json:"max_restarts,omitempty"`          // Relaunches after crashes per run; defaults to 2
+	LaunchLogWatch         int                     `json:"launch_log_watch,omitempty"`      // Seconds to watch Launch.log for known problems; -1 disables
+	LogLevel               string                  `json:"log_level,omitempty"`             // "debug", "info" (default), "warn" or "error"
+	LogFormat              string                  `json:"log_format,omitempty"`            // "text" (default) or "json"
+	LogMaxSize             int                     `json:"log_max_size,omitempty"`          // MB before slipstream.log is rotated; defaults to 5
+	LogMaxFiles            int                     `json:"log_max_files,omitempty"`         // Rotated log files to keep; defaults to 3
+	UpdateCheck            string                  `json:"update_check,omitempty"`          // "on" (default) or "off"
+	UpdateCheckInterval    int                     `json:"update_check_interval,omitempty"` // Hours between update checks; defaults to 24
+	UpdateChannel          string                  `json:"update_channel,omitempty"`        // "stable" (default) or "beta"
+	LastUpdateCheck        time.Time               `json:"last_update_check,omitzero"`
+	UpdateETag             string                  `json:"update_etag,omitempty"`
+	UpdateCheckChannel     string                  `json:"update_check_channel,omitempty"` // Channel of the last check, which LastUpdateCheck and UpdateETag belong to
+	UpdateCheckRetryAt     time.Time               `json:"update_check_retry_at,omitzero"` // Set when GitHub rate-limits the check
+	LastNotifiedVersion    string                  `json:"last_notified_version,omitempty"`
+	StopGracePeriod        int                     `json:"stop_grace_period,omitempty"` // Seconds to wait before force-killing on stop
+	AlreadyRunning         string                  `json:"already_running,omitempty"`   // "refuse" (default), "wait" or "exit"
+	PreLaunchHooks         []Hook                  `json:"pre_launch_hooks,omitempty"`
+	PostStartHooks         []Hook                  `json:"post_start_hooks,omitempty"`
+	PostExitHooks          []Hook                  `json:"post_exit_hooks,omitempty"`
+	VersionCheck           string                  `json:"version_check,omitempty"`      // "warn" (default), "block" or "off"
+	Runner                 RunnerConfig            `json:"runner"`                       // How the native Linux build runs the Windows game
+	Env                    map[string]string       `json:"env,omitempty"`                // Extra environment variables for the game
+	Wrapper                []string                `json:"wrapper,omitempty"`            // Command prefix, e.g. ["gamemoderun", "mangohud"]
+	WorkingDir             string                  `json:"working_dir,omitempty"`        // Defaults to the game executable's directory
+	Priority               string                  `json:"priority,omitempty"`           // "low", "below_normal", "normal", "above_normal" or "high"
+	LaunchArgs             []string                `json:"launch_args,omitempty"`        // Passed to the game before any command-line args
+	Presets                map[string]LaunchPreset `json:"presets,omitempty"`            // Selected with --preset=<name>
+	Language               string                  `json:"language,omitempty"`           // Rocket League language code, "auto" (default) or "off"
+	EpicDeploymentID       string                  `json:"epic_deployment_id,omitempty"` // Overrides the deployment ID from the install metadata
+	UI                     string                  `json:"ui,omitempty"`                 // "auto" (default), "zenity", "kdialog", "terminal" or "script"
+	UIScript               string                  `json:"ui_script,omitempty"`          // Answers for the "script" UI, one per line
 }
 
 // LaunchCredentials holds the final codes needed to start the game.
 type LaunchCredentials struct {
 	ExchangeCode string
 	AccountID    string
+	DisplayName  string
+	AccessToken  string // Used for read-only catalog queries before launch.
 }
 
 // apiResponse is used to decode all token/code responses from the Epic API.
@@ -60,35 +96,87 @@ type apiResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 	AccountID    string `json:"account_id"`
+	DisplayName  string `json:"displayName"`
 	Code         string `json:"code"`
 	ErrorCode    string `json:"errorCode"`
 	ErrorMessage string `json:"errorMessage"`
 }
 
+// registerSecrets keeps the response's tokens and codes out of logs and dialogs.
+func (r apiResponse) registerSecrets() {
+	registerSecret(r.AccessToken, r.RefreshToken, r.Code)
+}
+
 // Authenticator handles the Epic Games authentication flow.
 type Authenticator struct {
 	client *http.Client
+	apiURL string // Epic's account service; tests use a local server
 }
 
 // NewAuthenticator creates a new authenticator instance.
 func NewAuthenticator() *Authenticator {
+	client := &http.Client{}
+	if tracer := httpTracer(); tracer != nil {
+		client.Transport = tracer
+	}
 	return &Authenticator{
-		client: &http.Client{},
+		client: client,
+		apiURL: epicAPIURL,
 	}
 }
 
 // --- Main Application Logic ---
 
 func main() {
-	// Initialize file logging
-	logFile, err := os.OpenFile(filepath.Join(getExecutableDir(), "slipstream.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		log.Printf("Warning: Failed to open log file: %v", err)
-	} else {
-		defer logFile.Close()
-		// Create a multi-writer to write to both stdout and the log file
-		mw := io.MultiWriter(os.Stdout, logFile)
-		log.SetOutput(mw)
+	// Initialize logging to stdout and the rotating log file. Only the logging
+	// settings are needed here, so the config is read without any setup prompts.
+	logCfg, _ := readConfigFile()
+	closeLog := setupLogging(logCfg, isVerbose(os.Args[1:]))
+	defer closeLog()
+	slog.Info("Slipstream starting", "version", currentVersion, "os", runtime.GOOS, "args", len(os.Args)-1)
+	setupUI(logCfg)
+
+	// Commands that act on an existing installation instead of launching the game.
+	if len(os.Args) > 1 {
+		switch strings.ToLower(os.Args[1]) {
+		case "stop":
+			if err := runStopCommand(); err != nil {
+				log.Printf("Stop failed: %v", err)
+				os.Exit(1)
+			}
+			return
+		case "rollback":
+			if err := runRollbackCommand(); err != nil {
+				showError("Rollback Failed", "Could not restore the previous version of Slipstream.\n\nDetails: "+err.Error())
+				os.Exit(1)
+			}
+			return
+		case "verify":
+			if err := runVerifyCommand(); err != nil {
+				log.Printf("ERROR: Verification failed: %v", err)
+				os.Exit(1)
+			}
+			return
+		case "doctor":
+			if err := runDoctorCommand(os.Args[2:]); err != nil {
+				os.Exit(1)
+			}
+			return
+		case "settings":
+			if err := runSettingsCommand(); err != nil {
+				showError("Settings Error", "Could not save the settings.\n\nDetails: "+err.Error())
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	// Holding Shift while Slipstream starts opens the settings before launching.
+	if shiftHeld() {
+		log.Println("Shift is held, opening the settings.")
+		if err := runSettingsCommand(); err != nil {
+			showError("Settings Error", "Could not save the settings.\n\nDetails: "+err.Error())
+		}
 	}
 
 	// 1. Load configuration.
@@ -102,87 +190,296 @@ func main() {
 		return
 	}
 
+	// A dry run only shows the launch command, without logging in or starting anything.
+	if isDryRun(os.Args[1:]) {
+		creds := LaunchCredentials{ExchangeCode: "<exchange-code>", AccountID: "<account-id>", DisplayName: "<display-name>"}
+		if _, err := launchGame(cfg, creds, os.Args[1:]); err != nil {
+			log.Printf("Dry run failed: %v", err)
+		}
+		return
+	}
+
+	// Make sure the game isn't already running before a new token rotation.
+	proceed, err := claimSingleInstance(cfg)
+	if err != nil {
+		log.Printf("Warning: single-instance check failed: %v", err)
+	} else if !proceed {
+		return
+	}
+	defer removeInstanceLock()
+
 	// 2. Authenticate with Epic Games to get launch credentials.
 	auth := NewAuthenticator()
 	creds, newEpicToken, err := auth.GetLaunchCredentials(cfg.EpicToken)
 	if err != nil {
-		detailedMsg := "Authentication Failed.\n\n" +
-			"Your session may have expired or the authentication details are incorrect. The simplest fix is often to delete the 'config.json' file and run Slipstream again to log in from scratch.\n\n" +
-			"Details: " + err.Error()
-		showError("Authentication Failed", detailedMsg)
+		showAuthError(err)
 		return
 	}
 
 	// 3. Save the new token if it has changed.
-	if newEpicToken != "" && newEpicToken != cfg.EpicToken {
-		log.Println("Saving new session token.")
-		cfg.EpicToken = newEpicToken
-		if err := saveConfig(cfg); err != nil {
-			log.Printf("Warning: could not save new session token: %v", err)
-		}
+	saveEpicToken(&cfg, newEpicToken)
+
+	// Catch outdated or half-updated installs before they end in a "version mismatch".
+	if !checkGameVersion(cfg, auth, creds.AccessToken) {
+		return
 	}
 
 	// 4. Launch Rocket League with the obtained credentials and any extra args.
 	log.Println("Successfully authenticated. Launching Rocket League...")
 	// os.Args[0] is the program name, os.Args[1:] is all subsequent arguments.
 	// Updated to pass the full cfg object
-	if err := launchGame(cfg, creds, os.Args[1:]); err != nil {
-		detailedMsg := "Failed to Launch Rocket League.\n\n" +
-			"Please ensure the Rocket League path is correctly set in 'config.json' and that the game executable is not missing or corrupted.\n\n" +
-			"Details: " + err.Error()
-		showError("Failed to Launch Rocket League", detailedMsg)
+	session, err := launchGame(cfg, creds, os.Args[1:])
+	if err != nil {
+		showLaunchError(err)
 		return
 	}
 
-	log.Println("Game process started successfully.")
-
 	// 5. Check for updates in the background.
-	// Pass a pointer to cfg so the goroutine can modify it
+	// The checker gets its own copy of cfg and saves its state with updateConfig.
 	var wg sync.WaitGroup
 	wg.Add(1)
-	go checkForUpdates(&cfg, &wg)
+	go checkForUpdates(cfg, &wg)
+
+	// 6. Stay alive with the game so stop signals reach it and its companions.
+	// After a crash, the game may be relaunched with fresh credentials.
+	for restarts := 0; session != nil; restarts++ {
+		log.Println("Game process started successfully.")
+		if err := writeInstanceLock(session); err != nil {
+			log.Printf("Warning: could not write lock file: %v", err)
+		}
+		interrupted := session.supervise(stopGracePeriod(cfg))
+		if interrupted {
+			session.runPostExitHooks(cfg.PostExitHooks)
+			removeInstanceLock()
+			wg.Wait()
+			return
+		}
+		session.stopCompanionsWithGame(stopGracePeriod(cfg))
+		if session.diagnostics != nil {
+			<-session.diagnostics // Let any problem found in Launch.log be reported first.
+		}
+		session.runPostExitHooks(cfg.PostExitHooks)
+
+		exit := classifyGameExit(cfg, session)
+		if exit.Kind != exitCrash {
+			break
+		}
+		report, err := collectCrashReport(cfg, session, exit)
+		if err != nil {
+			log.Printf("Warning: could not save crash report: %v", err)
+		} else {
+			log.Printf("Crash report saved to %s", report)
+		}
+		if !confirmRestart(cfg, exit, report, restarts) {
+			break
+		}
+
+		// The exchange code is single-use, so every relaunch needs a new one.
+		log.Println("Relaunching Rocket League...")
+		reloadEpicToken(&cfg)
+		creds, newEpicToken, err = auth.GetLaunchCredentials(cfg.EpicToken)
+		if err != nil {
+			showAuthError(err)
+			break
+		}
+		saveEpicToken(&cfg, newEpicToken)
+		if session, err = launchGame(cfg, creds, os.Args[1:]); err != nil {
+			showLaunchError(err)
+			break
+		}
+	}
+	removeInstanceLock()
 
 	wg.Wait()
 }
 
+// saveEpicToken stores the refresh token if it has changed.
+func saveEpicToken(cfg *Config, token string) {
+	if token == "" || token == cfg.EpicToken {
+		return
+	}
+	log.Println("Saving new session token.")
+	cfg.EpicToken = token
+	if err := updateConfig(func(c *Config) { c.EpicToken = token }); err != nil {
+		log.Printf("Warning: could not save new session token: %v", err)
+	}
+}
+
+// reloadEpicToken picks up a refresh token saved while the game ran. 'Slipstream doctor'
+// uses the token and saves the one Epic rotates it to, which revokes the one in cfg.
+func reloadEpicToken(cfg *Config) {
+	saved, err := readConfigFile()
+	if err != nil {
+		log.Printf("Warning: could not reread the session token: %v", err)
+		return
+	}
+	if saved.EpicToken != "" && saved.EpicToken != cfg.EpicToken {
+		log.Println("Using the session token saved while the game was running.")
+		cfg.EpicToken = saved.EpicToken
+	}
+}
+
+func showAuthError(err error) {
+	detailedMsg := "Authentication Failed.\n\n" +
+		"Your session may have expired or the authentication details are incorrect. The simplest fix is often to delete the 'config.json' file and run Slipstream again to log in from scratch.\n\n" +
+		"Details: " + err.Error()
+	showError("Authentication Failed", detailedMsg)
+}
+
+func showLaunchError(err error) {
+	detailedMsg := "Failed to Launch Rocket League.\n\n" +
+		"Please ensure the Rocket League path is correctly set in 'config.json' and that the game executable is not missing or corrupted.\n\n" +
+		"Details: " + err.Error()
+	showError("Failed to Launch Rocket League", detailedMsg)
+}
+
 // --- Update Checker ---
 
+// releasesURL lists Slipstream's releases. It is a variable so a local release server can stand in for GitHub.
+var releasesURL = "https://api.github.com/repos/jun-eau/Slipstream/releases?per_page=50"
+
+const (
+	// Update channels for Config.UpdateChannel.
+	updateChannelStable = "stable"
+	updateChannelBeta   = "beta"
+
+	updateCheckOff             = "off"
+	defaultUpdateCheckInterval = 24 // hours
+	maxReleaseNotesLength      = 1500
+)
+
 // GitHubRelease represents the structure of a release from the GitHub API.
 type GitHubRelease struct {
-	TagName string `json:"tag_name"`
+	TagName    string         `json:"tag_name"`
+	HTMLURL    string         `json:"html_url"`
+	Draft      bool           `json:"draft"`
+	Prerelease bool           `json:"prerelease"`
+	Body       string         `json:"body"`
+	Assets     []releaseAsset `json:"assets"`
 }
 
-// isNewerVersion compares two version strings (e.g., "v1.5.1", "v1.6.0").
-// It returns true if the latest version is newer than the current version.
+// isNewerVersion compares two version tags (e.g., "v1.9.0", "v1.10.0-beta.1") by
+// semantic version precedence. It returns false if either tag is not a valid version.
 func isNewerVersion(current, latest string) bool {
-	// Simple string comparison works for "vX.Y.Z" format
-	return strings.TrimPrefix(latest, "v") > strings.TrimPrefix(current, "v")
+	cur, ok := parseSemver(current)
+	if !ok {
+		return false
+	}
+	next, ok := parseSemver(latest)
+	return ok && next.compare(cur) > 0
+}
+
+// newestRelease returns the highest-versioned release on the given channel. The stable
+// channel skips prereleases, both those marked on GitHub and those with a prerelease
+// tag; the beta channel includes them. Drafts and malformed tags are skipped.
+func newestRelease(releases []GitHubRelease, channel string) (GitHubRelease, bool) {
+	var best GitHubRelease
+	var bestVersion semVersion
+	found := false
+	for _, r := range releases {
+		v, ok := parseSemver(r.TagName)
+		if !ok {
+			slog.Debug("Ignoring release with malformed version tag", "tag", r.TagName)
+			continue
+		}
+		if r.Draft || (channel != updateChannelBeta && (r.Prerelease || v.isPrerelease())) {
+			continue
+		}
+		if !found || v.compare(bestVersion) > 0 {
+			best, bestVersion, found = r, v, true
+		}
+	}
+	return best, found
 }
 
-// checkForUpdates fetches the latest release from GitHub and notifies the user if it's a new version.
+// checkForUpdates fetches the releases from GitHub and notifies the user if a newer
+// version is available on their update channel.
 // It runs in a goroutine to avoid blocking the main application flow.
-func checkForUpdates(cfg *Config, wg *sync.WaitGroup) {
+func checkForUpdates(cfg Config, wg *sync.WaitGroup) {
 	defer wg.Done()
-	log.Println("Checking for application updates...")
+	if strings.ToLower(cfg.UpdateCheck) == updateCheckOff {
+		log.Println("Update check is turned off.")
+		return
+	}
+	channel := strings.ToLower(cfg.UpdateChannel)
+	if channel == "" {
+		channel = updateChannelStable
+	}
+	// The last check says nothing about the releases of another channel.
+	if orDefault(cfg.UpdateCheckChannel, updateChannelStable) != channel {
+		cfg.LastUpdateCheck, cfg.UpdateETag = time.Time{}, ""
+	}
+	now := time.Now()
+	if now.Before(cfg.UpdateCheckRetryAt) {
+		log.Printf("Skipping update check until %s (GitHub rate limit).", cfg.UpdateCheckRetryAt.Format(time.RFC3339))
+		return
+	}
+	if next := cfg.LastUpdateCheck.Add(updateCheckInterval(cfg)); now.Before(next) {
+		slog.Debug("Skipping update check", "last_check", cfg.LastUpdateCheck)
+		return
+	}
+
+	log.Printf("Checking for application updates (%s channel)...", channel)
+	req, err := http.NewRequest(http.MethodGet, releasesURL, nil)
+	if err != nil {
+		log.Printf("Update check failed: %v", err)
+		return
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if cfg.UpdateETag != "" {
+		req.Header.Set("If-None-Match", cfg.UpdateETag)
+	}
 	// Use a longer timeout for the update check
 	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get("https://api.github.com/repos/jun-eau/Slipstream/releases/latest")
+	resp, err := client.Do(req)
 	if err != nil {
 		log.Printf("Update check failed (network error): %v", err)
 		return
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if retryAt, limited := rateLimitRetryTime(resp, now); limited {
+		log.Printf("Update check rate limited by GitHub (status code: %d), next check after %s.", resp.StatusCode, retryAt.Format(time.RFC3339))
+		if err := updateConfig(func(c *Config) { c.UpdateCheckRetryAt = retryAt }); err != nil {
+			log.Printf("Warning: failed to save update check state: %v", err)
+		}
+		return
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		// Conditional requests answered from GitHub's cache don't count against the rate limit.
+		log.Println("No new releases since the last update check.")
+		if err := updateConfig(func(c *Config) { c.LastUpdateCheck = now }); err != nil {
+			log.Printf("Warning: failed to save update check state: %v", err)
+		}
+		return
+	default:
 		log.Printf("Update check failed (status code: %d)", resp.StatusCode)
 		return
 	}
 
-	var release GitHubRelease
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+	var releases []GitHubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
 		log.Printf("Update check failed (JSON parsing error): %v", err)
 		return
 	}
+	etag := resp.Header.Get("ETag")
+	err = updateConfig(func(c *Config) {
+		c.LastUpdateCheck = now
+		c.UpdateETag = etag
+		c.UpdateCheckChannel = channel
+		c.UpdateCheckRetryAt = time.Time{}
+	})
+	if err != nil {
+		log.Printf("Warning: failed to save update check state: %v", err)
+	}
+	release, ok := newestRelease(releases, channel)
+	if !ok {
+		log.Println("Update check found no releases.")
+		return
+	}
 
 	latestVersion := release.TagName
 	log.Printf("Current version: %s, Latest version: %s", currentVersion, latestVersion)
@@ -193,38 +490,108 @@ func checkForUpdates(cfg *Config, wg *sync.WaitGroup) {
 		if cfg.LastNotifiedVersion != latestVersion {
 			log.Println("Notifying user about the new version.")
 			// Use a separate function to show the dialog to keep this clean
-			showUpdateNotification(latestVersion)
+			showUpdateNotification(release)
 
 			// Update the config and save it
-			cfg.LastNotifiedVersion = latestVersion
-			if err := saveConfig(*cfg); err != nil {
+			if err := updateConfig(func(c *Config) { c.LastNotifiedVersion = latestVersion }); err != nil {
 				log.Printf("Warning: failed to save last notified version: %v", err)
 			}
 		} else {
-			log.Printf("Already notified user about version %s. Skipping.", latestVersion)
+			slog.Debug("Already notified user about this version", "version", latestVersion)
 		}
 	} else {
 		log.Println("Application is up to date.")
 	}
 }
 
+// updateCheckInterval returns how long to wait between update checks.
+func updateCheckInterval(cfg Config) time.Duration {
+	if cfg.UpdateCheckInterval <= 0 {
+		return defaultUpdateCheckInterval * time.Hour
+	}
+	return time.Duration(cfg.UpdateCheckInterval) * time.Hour
+}
+
+// rateLimitRetryTime reports whether GitHub refused the request because of a rate
+// limit, and when to try again: after Retry-After for secondary limits, or at
+// X-RateLimit-Reset once the hourly quota is used up.
+func rateLimitRetryTime(resp *http.Response, now time.Time) (time.Time, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return now.Add(time.Duration(seconds) * time.Second), true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Unix(reset, 0), true
+		}
+		return now.Add(time.Hour), true
+	}
+	return time.Time{}, false
+}
+
+// releaseNotes turns a release body written in Markdown into plain text for the
+// update dialog, shortened to fit.
+func releaseNotes(body string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " ")
+		trimmed := strings.TrimLeft(line, " ")
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			line = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+		case strings.HasPrefix(trimmed, "- "), strings.HasPrefix(trimmed, "* "):
+			line = line[:len(line)-len(trimmed)] + "• " + trimmed[2:]
+		}
+		line = strings.NewReplacer("**", "", "__", "", "`", "").Replace(line)
+		if line == "" && (len(lines) == 0 || lines[len(lines)-1] == "") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	notes := strings.TrimSpace(strings.Join(lines, "\n"))
+	if len(notes) > maxReleaseNotesLength {
+		cut := strings.LastIndex(notes[:maxReleaseNotesLength], "\n")
+		if cut <= 0 {
+			cut = maxReleaseNotesLength
+		}
+		notes = notes[:cut] + "\n…"
+	}
+	return notes
+}
+
 // showUpdateNotification displays the update dialog to the user.
-func showUpdateNotification(version string) {
+func showUpdateNotification(release GitHubRelease) {
 	message := fmt.Sprintf(
 		"A new version of Slipstream is available!\n\n"+
 			"You are on version: %s\n"+
-			"The latest version is: %s\n\n"+
-			"You can download the new version from the releases page.",
-		currentVersion, version,
+			"The latest version is: %s\n\n",
+		currentVersion, release.TagName,
 	)
-	err := zenity.Question(message,
-		zenity.Title("Update Available"),
-		zenity.OKLabel("Download"),
-		zenity.CancelLabel("Ignore"),
-		zenity.InfoIcon,
-	)
-	if err == nil {
-		openBrowser("https://github.com/jun-eau/Slipstream/releases/latest")
+	if notes := releaseNotes(release.Body); notes != "" {
+		message += "What's new:\n" + notes + "\n\n"
+	}
+	message += "You can download the new version from the releases page."
+	releasePage := orDefault(release.HTMLURL, "https://github.com/jun-eau/Slipstream/releases/latest")
+	if !canSelfUpdate(release) {
+		if askQuestion(question{Title: "Update Available", Message: message, OK: "Download", Cancel: "Ignore", Icon: iconInfo}) == answerOK {
+			openBrowser(releasePage)
+		}
+		return
+	}
+
+	switch askQuestion(question{Title: "Update Available", Message: message, OK: "Update Now", Extra: "Release Page", Cancel: "Ignore", Icon: iconInfo}) {
+	case answerOK:
+		if err := selfUpdate(release); err != nil {
+			showError("Update Failed", "Slipstream could not be updated. Your current version was not changed.\n\n"+
+				"You can download the new version from the releases page instead.\n\nDetails: "+err.Error())
+			return
+		}
+		showInfo("Update Complete", fmt.Sprintf("Slipstream was updated to %s. The new version will be used the next time you start it.\n\n"+
+			"If something goes wrong, run 'Slipstream rollback' to go back to %s.", release.TagName, currentVersion))
+	case answerExtra:
+		openBrowser(releasePage)
 	}
 }
 
@@ -288,38 +655,35 @@ func (a *Authenticator) GetLaunchCredentials(currentToken string) (LaunchCredent
 
 	creds.ExchangeCode = exchangeResp.Code
 	creds.AccountID = tokenResp.AccountID
+	creds.DisplayName = tokenResp.DisplayName
+	creds.AccessToken = tokenResp.AccessToken
 	return creds, newRefreshToken, nil
 }
 
 // launchGame starts the game with the provided credentials and arguments.
-// On Linux, it detects if the target is a Windows executable and guides the user.
-// The function signature now takes the full Config object.
-func launchGame(cfg Config, creds LaunchCredentials, extraArgs []string) error {
-	// 1. Preserve existing Linux .exe check.
+// On Linux, Windows executables are started through the configured runner; without
+// one, the user is guided to run Slipstream.exe under Proton instead.
+// The returned session is nil when nothing was launched.
+func launchGame(cfg Config, creds LaunchCredentials, extraArgs []string) (*gameSession, error) {
+	// 1. Without a runner, the native Linux build only performs the initial setup.
 	// This ensures the "Setup Complete" message appears correctly on Linux
 	// before any launch attempt is made.
-	if runtime.GOOS == "linux" && strings.HasSuffix(strings.ToLower(cfg.RocketLeaguePath), ".exe") {
+	if needsRunner(cfg.RocketLeaguePath) && cfg.Runner.Type == "" {
 		showInfo("Setup Complete!",
 			"Your configuration and login token have been successfully saved to 'config.json'.\n\n"+
 				"To play, please add 'Slipstream.exe' (the Windows version) to Steam or Lutris and run it using Proton or Wine. "+
-				"It will use the config file you just created.")
-		return nil // Expected outcome on Linux with .exe path
-	}
-
-	useEAC := true
-	filteredExtraArgs := []string{}
-	for _, arg := range extraArgs {
-		argLower := strings.ToLower(arg)
-		if argLower == "-noeac" {
-			useEAC = false
-		} else if strings.HasPrefix(argLower, "--config=") {
-			// Do nothing, just skip the --config= flag
-		} else {
-			filteredExtraArgs = append(filteredExtraArgs, arg)
-		}
+				"It will use the config file you just created.\n\n"+
+				"Alternatively, set a \"runner\" (wine, proton or umu) in 'config.json' and this Linux version will launch the game itself.")
+		return nil, nil // Expected outcome on Linux with .exe path
 	}
 
-	if cfg.BakkesModEnabled {
+	// Merge the configured launch args and preset with the command line.
+	filteredExtraArgs, useEAC, err := resolveLaunchArgs(cfg, extraArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	if companionsDisableEAC(cfg.Companions) {
 		useEAC = false
 	}
 
@@ -328,6 +692,16 @@ func launchGame(cfg Config, creds LaunchCredentials, extraArgs []string) error {
 	rlFilenameLower := strings.ToLower(rlFilename)
 
 	if rlFilenameLower == "rocketleague.exe" || rlFilenameLower == "rocketleague_eac.exe" {
+		// Catch a broken EAC setup now rather than with an error after the game starts.
+		if useEAC {
+			switch confirmEAC(cfg, rlDir, isDryRun(extraArgs)) {
+			case eacOffline:
+				useEAC = false
+			case eacCancel:
+				log.Println("Launch cancelled by user.")
+				return nil, nil
+			}
+		}
 		if useEAC {
 			cfg.RocketLeaguePath = filepath.Join(rlDir, "RocketLeague_EAC.exe")
 		} else {
@@ -337,62 +711,102 @@ func launchGame(cfg Config, creds LaunchCredentials, extraArgs []string) error {
 
 	// 2. Launch Rocket League (asynchronously).
 	log.Printf("Launching Rocket League... (Executable: %s)", cfg.RocketLeaguePath)
-	rlArgs := []string{
-		"-AUTH_LOGIN=unused",
-		"-AUTH_PASSWORD=" + creds.ExchangeCode,
-		"-AUTH_TYPE=exchangecode",
-		"-epicapp=Sugar",
-		"-epicenv=Prod",
-		"-EpicPortal",
-		"-epicusername=\"\"", // Intentionally empty as per original args
-		"-epicuserid=" + creds.AccountID,
-	}
-	rlArgs = append(rlArgs, filteredExtraArgs...)
-	rlCmd := exec.Command(cfg.RocketLeaguePath, rlArgs...)
-
-	if err := rlCmd.Start(); err != nil {
-		return fmt.Errorf("failed to start Rocket League at %s: %w", cfg.RocketLeaguePath, err)
+	// Match the Epic Games Launcher's arguments; the user's own args can override or drop
+	// any of them except the login arguments.
+	locale := systemLocale()
+	install, _ := findInstallInfo(cfg.RocketLeaguePath)
+	rlArgs := epicLaunchArgs(creds, locale, install.CatalogNamespace, orDefault(cfg.EpicDeploymentID, install.DeploymentID))
+	if arg := languageArg(cfg.Language, locale); arg != "" {
+		rlArgs = append(rlArgs, arg)
+	}
+	rlArgs = mergeLaunchArgs(rlArgs, filteredExtraArgs)
+	rlCmd, err := runnerCommand(cfg.Runner, cfg.RocketLeaguePath, rlArgs...)
+	if err != nil {
+		return nil, err
+	}
+	if rlCmd, err = applyLaunchEnvironment(rlCmd, cfg, cfg.RocketLeaguePath); err != nil {
+		return nil, err
+	}
+	setCommandLine(rlCmd)
+	logLaunchCommand("Rocket League", rlCmd, cfg)
+	if isDryRun(extraArgs) {
+		log.Println("Dry run: not starting Rocket League.")
+		return nil, nil
+	}
+
+	hookCtx := hookContext{
+		Profile:   profileName(),
+		AccountID: creds.AccountID,
+		EAC:       useEAC,
+		GamePath:  cfg.RocketLeaguePath,
+	}
+	if err := runHooks(hookPreLaunch, cfg.PreLaunchHooks, hookCtx); err != nil {
+		return nil, err
+	}
+
+	// Only lines written after this point belong to this launch.
+	logPath := launchLogPath(cfg)
+	logStart := logEnd(logPath)
+
+	game, err := startTracked("Rocket League", rlCmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start Rocket League at %s: %w", cfg.RocketLeaguePath, err)
 	}
 	log.Println("Rocket League process started.")
+	hookCtx.GamePID = game.pid()
+	session := newGameSession(game, hookCtx)
+	session.launchLog, session.launchLogStart = logPath, logStart
 
-	// 3. Conditional BakkesMod Launch.
-	if !cfg.BakkesModEnabled || cfg.BakkesModPath == "" {
-		log.Println("BakkesMod is not enabled or path is not set. Launch complete.")
-		return nil // Standard launch finished successfully.
-	}
-
-	// 4. Execute BakkesMod launch sequence.
-	// We need to import "time" for this. Ensure it's added if not already.
-	// (It should be, due to existing logging, but good to double check)
-	delay := time.Duration(cfg.BakkesModLaunchDelay) * time.Second
-	log.Printf("BakkesMod is enabled. Waiting for %v before launching...", delay)
-	time.Sleep(delay) // Requires "time" package
-
-	log.Println("Launching BakkesMod...")
-	bmCmd := exec.Command(cfg.BakkesModPath) // No arguments needed for BakkesMod.exe
-	if err := bmCmd.Start(); err != nil {
-		// Inform the user but do not treat it as a fatal error for the game itself.
-		errorMsg := fmt.Sprintf(
-			"Could not start BakkesMod.exe at the specified path:\n\n%s\n\nError: %v\n\nRocket League should still be running.",
-			cfg.BakkesModPath, err,
-		)
-		showError("BakkesMod Launch Failed", errorMsg)
-		log.Printf("Error launching BakkesMod: %v", err)
-		// Non-fatal error, Rocket League is (presumably) running.
-	} else {
-		log.Println("BakkesMod process started.")
+	if err := runHooks(hookPostStart, cfg.PostStartHooks, hookCtx); err != nil {
+		session.stop(stopGracePeriod(cfg))
+		return nil, err
+	}
+
+	// 3. Start companion apps in the background once their start triggers fire.
+	startCompanions(cfg, session)
+
+	if window := launchLogWatchWindow(cfg); window > 0 && logPath != "" {
+		session.diagnostics = watchLaunchLog(logPath, logStart, window, session.ended)
 	}
 
-	return nil // Two-process launch sequence finished (or attempted).
+	return session, nil
 }
 
 // --- Authentication Steps ---
 
 func (a *Authenticator) performFirstTimeSetup() (string, error) {
-	showInfo("Authorization Required", "A browser window will now open. Please log in to your Epic Games account, then copy the 'authorizationCode' value.")
-	
-	log.Println("Opening browser for login...")
-	openBrowser(epicLoginRedirect)
+	// On a Steam Deck or TV the browser on the device itself is awkward, so the login
+	// can also be done on a phone by scanning a QR code of the login link.
+	choice := askQuestion(question{
+		Title:   "Authorization Required",
+		Message: "Please log in to your Epic Games account, then copy the 'authorizationCode' value.\n\nYou can log in with a browser on this device, or on your phone by scanning a QR code.",
+		OK:      "Open Browser",
+		Extra:   "Use Phone",
+		Cancel:  "Cancel",
+		Icon:    iconInfo,
+	})
+	switch choice {
+	case answerOK:
+		log.Println("Opening browser for login...")
+		openBrowser(epicLoginRedirect)
+	case answerExtra:
+		log.Println("Showing login QR code...")
+		code, err := encodeQR(epicLoginRedirect)
+		if err != nil {
+			return "", fmt.Errorf("could not create the login QR code: %w", err)
+		}
+		// Keep the image until the code has been entered, in case it is opened late.
+		image, err := saveQRImage(code)
+		if err != nil {
+			log.Printf("Warning: could not save the QR code image: %v", err)
+		} else {
+			defer os.Remove(image)
+		}
+		ui.QRCode("Log In With Your Phone", "Scan this code with your phone's camera and log in to your Epic Games account.\n\n"+
+			"Epic then shows a page with an 'authorizationCode' value. Enter that code in the next window.", code, image)
+	default:
+		return "", fmt.Errorf("user cancelled login")
+	}
 
 	// LOG TO COMMAND PROMPT: This acts as your indestructible fallback.
 	fmt.Printf("\n------------------------------------------------------------\n")
@@ -420,6 +834,7 @@ func (a *Authenticator) performFirstTimeSetup() (string, error) {
 }
 
 func (a *Authenticator) exchangeAuthCode(code string) (apiResponse, error) {
+	registerSecret(code)
 	data := url.Values{}
 	data.Set("grant_type", "authorization_code")
 	data.Set("code", code)
@@ -427,6 +842,7 @@ func (a *Authenticator) exchangeAuthCode(code string) (apiResponse, error) {
 }
 
 func (a *Authenticator) exchangeRefreshToken(token string) (apiResponse, error) {
+	registerSecret(token)
 	data := url.Values{}
 	data.Set("grant_type", "refresh_token")
 	data.Set("refresh_token", token)
@@ -437,6 +853,7 @@ func (a *Authenticator) exchangeRefreshToken(token string) (apiResponse, error)
 func (a *Authenticator) makeTokenRequest(data url.Values) (apiResponse, error) {
 	var resp apiResponse
 	err := a.apiRequest("POST", tokenPath, data, epicLauncherAuth, &resp)
+	resp.registerSecrets()
 	if err != nil {
 		return resp, fmt.Errorf("token request failed: %w", err)
 	}
@@ -447,9 +864,11 @@ func (a *Authenticator) makeTokenRequest(data url.Values) (apiResponse, error) {
 }
 
 func (a *Authenticator) getExchangeCode(accessToken string) (apiResponse, error) {
+	registerSecret(accessToken)
 	var resp apiResponse
 	authHeader := "bearer " + accessToken
 	err := a.apiRequest("GET", exchangePath, nil, authHeader, &resp)
+	resp.registerSecrets()
 	if err != nil {
 		return resp, fmt.Errorf("exchange code request failed: %w", err)
 	}
@@ -462,12 +881,16 @@ func (a *Authenticator) getExchangeCode(accessToken string) (apiResponse, error)
 // --- Generic API Request Helper ---
 
 func (a *Authenticator) apiRequest(method, path string, data url.Values, authHeader string, target interface{}) error {
+	return a.requestURL(method, a.apiURL+path, data, authHeader, target)
+}
+
+func (a *Authenticator) requestURL(method, reqURL string, data url.Values, authHeader string, target interface{}) error {
 	var reqBody io.Reader
 	if data != nil {
 		reqBody = strings.NewReader(data.Encode())
 	}
 
-	req, err := http.NewRequest(method, epicAPIURL+path, reqBody)
+	req, err := http.NewRequest(method, reqURL, reqBody)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -505,25 +928,34 @@ func getConfigFileName() string {
 	return configFileName
 }
 
-func loadConfig() (Config, error) {
+// readConfigFile reads the config file without prompting the user for anything.
+func readConfigFile() (Config, error) {
 	var cfg Config
-	path := filepath.Join(getExecutableDir(), getConfigFileName())
-
-	file, err := os.ReadFile(path)
-	if err == nil {
-		json.Unmarshal(file, &cfg)
+	file, err := os.ReadFile(filepath.Join(getExecutableDir(), getConfigFileName()))
+	if err != nil {
+		return cfg, err
 	}
+	err = json.Unmarshal(file, &cfg)
+	registerSecret(cfg.EpicToken)
+	return cfg, err
+}
+
+// profileName identifies the active config file, e.g. "smurf" for --config=smurf.json.
+func profileName() string {
+	name := filepath.Base(getConfigFileName())
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+func loadConfig() (Config, error) {
+	cfg, _ := readConfigFile()
 
 	// If the path is missing, always prompt for it.
 	if cfg.RocketLeaguePath == "" {
 		showInfo("Rocket League Path Setup", "Please locate and select RocketLeague_EAC.exe (usually found in Binaries/Win64).")
-		rlPath, err := zenity.SelectFile(
-			zenity.Title("Select RocketLeague_EAC.exe"),
-			zenity.FileFilters{
-				{Name: "Rocket League Executable", Patterns: []string{"RocketLeague.exe", "RocketLeague_EAC.exe", "RocketLeague"}, CaseFold: true},
-				{Name: "All Files", Patterns: []string{"*"}},
-			},
-		)
+		rlPath, err := ui.SelectFile("Select RocketLeague_EAC.exe", []fileFilter{
+			{Name: "Rocket League Executable", Patterns: []string{"RocketLeague.exe", "RocketLeague_EAC.exe", "RocketLeague"}},
+			{Name: "All Files", Patterns: []string{"*"}},
+		})
 		if err != nil {
 			return cfg, fmt.Errorf("you must select a Rocket League path to continue")
 		}
@@ -535,32 +967,33 @@ func loadConfig() (Config, error) {
 		}
 	}
 
+	// Move legacy BakkesMod settings into the companions list.
+	if migrateBakkesModConfig(&cfg) {
+		if err := saveConfig(cfg); err != nil {
+			log.Printf("Warning: could not save migrated BakkesMod settings: %v", err)
+		}
+	}
+
 	// BakkesMod Setup Prompt - only if RL path is set and BM not already configured or declined
-	if cfg.RocketLeaguePath != "" && cfg.BakkesModPath == "" && !cfg.BakkesModSetupDeclined {
+	if cfg.RocketLeaguePath != "" && findCompanion(cfg.Companions, bakkesModName) == nil && !cfg.BakkesModSetupDeclined {
 		log.Println("Prompting for BakkesMod setup.")
-		err := zenity.Question("Would you like to enable legacy BakkesMod support?\n\nWARNING: BakkesMod has been discontinued and no longer works online. Enabling this will launch the game without Anti-Cheat, meaning you will only be able to play offline modes (Free Play, Replays, Custom Training).",
-			zenity.Title("BakkesMod Setup (Legacy/Offline)"),
-			zenity.DefaultCancel(), // Makes "No" the default if user just closes dialog
-			zenity.OKLabel("Yes"),
-			zenity.CancelLabel("No"),
-		)
-
-		if err == nil { // User clicked "Yes"
+		answer := askQuestion(question{
+			Title:         "BakkesMod Setup (Legacy/Offline)",
+			Message:       "Would you like to enable legacy BakkesMod support?\n\nWARNING: BakkesMod has been discontinued and no longer works online. Enabling this will launch the game without Anti-Cheat, meaning you will only be able to play offline modes (Free Play, Replays, Custom Training).",
+			OK:            "Yes",
+			Cancel:        "No",
+			DefaultCancel: true, // Makes "No" the default if user just closes dialog
+		})
+
+		if answer == answerOK { // User clicked "Yes"
 			log.Println("User opted to set up BakkesMod.")
-			bmPath, err := zenity.SelectFile(
-				zenity.Title("Select BakkesMod.exe"),
-				zenity.FileFilters{
-					{Name: "BakkesMod Executable", Patterns: []string{"BakkesMod.exe"}, CaseFold: true},
-					{Name: "All Files", Patterns: []string{"*"}},
-				},
-			)
+			bmPath, err := ui.SelectFile("Select BakkesMod.exe", []fileFilter{
+				{Name: "BakkesMod Executable", Patterns: []string{"BakkesMod.exe"}},
+				{Name: "All Files", Patterns: []string{"*"}},
+			})
 			if err == nil && bmPath != "" {
 				log.Printf("BakkesMod path selected: %s", bmPath)
-				cfg.BakkesModPath = bmPath
-				cfg.BakkesModEnabled = true
-				if cfg.BakkesModLaunchDelay == 0 { // Set default delay if not already set by user
-					cfg.BakkesModLaunchDelay = 5
-				}
+				cfg.Companions = append(cfg.Companions, newBakkesModCompanion(bmPath, defaultCompanionDelay, true))
 				cfg.BakkesModSetupDeclined = false // Ensure this is false if they just set it up
 			} else {
 				log.Println("User did not select a BakkesMod path or cancelled.")
@@ -572,7 +1005,6 @@ func loadConfig() (Config, error) {
 		} else { // User clicked "No" or closed the dialog
 			log.Println("User declined BakkesMod setup.")
 			cfg.BakkesModSetupDeclined = true
-			cfg.BakkesModEnabled = false // Ensure it's disabled if they decline
 		}
 		// Save config after BM interaction (or lack thereof)
 		if err := saveConfig(cfg); err != nil {
@@ -580,20 +1012,33 @@ func loadConfig() (Config, error) {
 		}
 	}
 
-	// Ensure default launch delay if enabled and not set
-	if cfg.BakkesModEnabled && cfg.BakkesModLaunchDelay == 0 {
-		log.Println("BakkesMod enabled but launch delay is 0, setting to default (5s).")
-		cfg.BakkesModLaunchDelay = 5
-		if err := saveConfig(cfg); err != nil {
-			// Log this, but it's not critical enough to halt the app
-			log.Printf("Warning: could not save default BakkesMod launch delay: %v", err)
-		}
-	}
-
 	return cfg, nil
 }
 
+// configMu serializes writes to the config file, which the update checker also
+// saves to while the game is running.
+var configMu sync.Mutex
+
 func saveConfig(cfg Config) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+	return writeConfigFile(cfg)
+}
+
+// updateConfig applies change to the config file as it is on disk and saves it, so
+// that fields saved by another goroutine in the meantime are kept.
+func updateConfig(change func(*Config)) error {
+	configMu.Lock()
+	defer configMu.Unlock()
+	cfg, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	change(&cfg)
+	return writeConfigFile(cfg)
+}
+
+func writeConfigFile(cfg Config) error {
 	path := filepath.Join(getExecutableDir(), getConfigFileName())
 	data, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
@@ -604,8 +1049,12 @@ func saveConfig(cfg Config) error {
 
 // --- Utility Helpers ---
 
+// executablePath returns the path of the running executable. It is a variable so
+// tests can run against a temporary install.
+var executablePath = os.Executable
+
 func getExecutableDir() string {
-	ex, err := os.Executable()
+	ex, err := executablePath()
 	if err != nil {
 		dir, _ := os.Getwd()
 		return dir
@@ -631,16 +1080,24 @@ func openBrowser(url string) {
 // --- GUI Dialog Functions ---
 
 func showError(title, message string) {
+	message = redact(message)
 	log.Printf("ERROR: %s - %s", title, message)
-	zenity.Error(message, zenity.Title(title), zenity.ErrorIcon)
+	ui.Error(title, message)
 }
 
 func showInfo(title, message string) {
+	message = redact(message)
 	log.Printf("INFO: %s - %s", title, message)
-	zenity.Info(message, zenity.Title(title), zenity.InfoIcon)
+	ui.Info(title, message)
 }
 
 func askForInput(title, message string) (string, error) {
 	log.Printf("PROMPT: %s", title)
-	return zenity.Entry(message, zenity.Title(title))
+	return ui.Entry(title, message, "")
+}
+
+func askQuestion(q question) answer {
+	q.Message = redact(q.Message)
+	log.Printf("PROMPT: %s", q.Title)
+	return ui.Question(q)
 }
@@ -0,0 +1,63 @@
+// main_test.go
+package main
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestUpdateCheckAfterChannelChange checks that a recent check of the stable channel
+// doesn't stop beta releases from being offered after switching to beta.
+func TestUpdateCheckAfterChannelChange(t *testing.T) {
+	useTempInstall(t)
+	serveRelease(t, "v99.0.0-beta.1", nil)
+	dialogs := useScriptedUI(t, "Ignore")
+	cfg := Config{
+		UpdateChannel:      updateChannelBeta,
+		LastUpdateCheck:    time.Now(),
+		UpdateETag:         `"test-etag"`,
+		UpdateCheckChannel: updateChannelStable,
+	}
+	writeTestConfig(t, cfg)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	checkForUpdates(cfg, &wg)
+
+	if !slices.Equal(dialogs.Shown, []string{"Update Available"}) {
+		t.Errorf("dialogs = %q, want the beta release to be offered", dialogs.Shown)
+	}
+	saved, _ := readConfigFile()
+	if saved.UpdateCheckChannel != updateChannelBeta || saved.LastNotifiedVersion != "v99.0.0-beta.1" {
+		t.Errorf("saved update state = %q, %q", saved.UpdateCheckChannel, saved.LastNotifiedVersion)
+	}
+
+	// The next check of the same channel is skipped until the interval has passed.
+	dialogs.Shown = nil
+	wg.Add(1)
+	checkForUpdates(saved, &wg)
+	if len(dialogs.Shown) > 0 {
+		t.Errorf("dialogs = %q, want the check to be skipped", dialogs.Shown)
+	}
+}
+
+// TestRelaunchAfterDoctor checks that a relaunch uses the refresh token 'doctor' saved
+// while the game ran, rather than the one it revoked.
+func TestRelaunchAfterDoctor(t *testing.T) {
+	useTempInstall(t)
+	dialogs := useScriptedUI(t)
+	epic := fakeEpic{refreshToken: "rotatedByDoctorToken", accessToken: "relaunchAccessToken", exchangeCode: "relaunchExchangeCode"}
+	writeTestConfig(t, Config{EpicToken: epic.refreshToken})
+	cfg := Config{EpicToken: "revokedByDoctorToken"}
+
+	reloadEpicToken(&cfg)
+	creds, _, err := epic.serve(t).GetLaunchCredentials(cfg.EpicToken)
+	if err != nil {
+		t.Fatalf("relaunch login failed: %v", err)
+	}
+	if creds.ExchangeCode != epic.exchangeCode || len(dialogs.Shown) > 0 {
+		t.Errorf("credentials = %+v, dialogs = %q", creds, dialogs.Shown)
+	}
+}
@@ -0,0 +1,301 @@
+// manifest.go
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf16"
+)
+
+// Binary Epic manifest constants. The format is the one written by the Epic Games
+// Launcher and Legendary: a small header followed by an optionally zlib-compressed
+// body holding the metadata, chunk data list and file manifest list.
+const (
+	manifestMagic          = 0x44BEC00C
+	manifestFlagCompressed = 0x1
+	manifestFlagEncrypted  = 0x2
+)
+
+// epicManifest is a parsed binary Epic manifest.
+type epicManifest struct {
+	AppName      string
+	BuildVersion string
+	LaunchExe    string
+	Files        []manifestFile
+}
+
+// manifestFile is a file entry of the file manifest list.
+type manifestFile struct {
+	Filename   string
+	SHA1       [20]byte
+	ChunkParts []manifestChunkPart
+}
+
+// manifestChunkPart locates a piece of a file inside a downloadable chunk.
+type manifestChunkPart struct {
+	GUID   [4]uint32
+	Offset uint32
+	Size   uint32
+}
+
+// Size is the file size, the sum of all its chunk parts.
+func (f manifestFile) Size() int64 {
+	var size int64
+	for _, part := range f.ChunkParts {
+		size += int64(part.Size)
+	}
+	return size
+}
+
+func readEpicManifestFile(path string) (*epicManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseEpicManifest(data)
+}
+
+// parseEpicManifest decodes a binary Epic manifest.
+func parseEpicManifest(data []byte) (*epicManifest, error) {
+	if len(data) > 0 && data[0] == '{' {
+		return nil, errors.New("JSON manifests are not supported")
+	}
+
+	r := &manifestReader{r: bytes.NewReader(data)}
+	magic := r.u32()
+	headerSize := r.u32()
+	sizeUncompressed := r.u32()
+	sizeCompressed := r.u32()
+	var hash [20]byte
+	r.read(hash[:])
+	storedAs := r.u8()
+	if r.err != nil {
+		return nil, fmt.Errorf("manifest header: %w", r.err)
+	}
+	if magic != manifestMagic {
+		return nil, fmt.Errorf("not an Epic manifest (magic %#x)", magic)
+	}
+	if storedAs&manifestFlagEncrypted != 0 {
+		return nil, errors.New("encrypted manifests are not supported")
+	}
+	if int(headerSize) > len(data) {
+		return nil, errors.New("manifest header is truncated")
+	}
+
+	body := data[headerSize:]
+	if storedAs&manifestFlagCompressed != 0 {
+		if int(sizeCompressed) < len(body) {
+			body = body[:sizeCompressed]
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("manifest body: %w", err)
+		}
+		body, err = io.ReadAll(io.LimitReader(zr, int64(sizeUncompressed)+1))
+		zr.Close()
+		if err != nil {
+			return nil, fmt.Errorf("manifest body: %w", err)
+		}
+	}
+	if uint32(len(body)) != sizeUncompressed {
+		return nil, fmt.Errorf("manifest body is %d bytes, expected %d", len(body), sizeUncompressed)
+	}
+	if sha1.Sum(body) != hash {
+		return nil, errors.New("manifest body hash mismatch")
+	}
+
+	m := &epicManifest{}
+	br := &manifestReader{r: bytes.NewReader(body)}
+	if err := br.section(m.readMeta); err != nil {
+		return nil, fmt.Errorf("manifest metadata: %w", err)
+	}
+	if err := br.section(m.readChunkList); err != nil {
+		return nil, fmt.Errorf("manifest chunk list: %w", err)
+	}
+	if err := br.section(m.readFileList); err != nil {
+		return nil, fmt.Errorf("manifest file list: %w", err)
+	}
+	return m, nil
+}
+
+func (m *epicManifest) readMeta(r *manifestReader) {
+	dataVersion := r.u8()
+	r.u32() // feature level
+	r.u8()  // is file data
+	r.u32() // app id
+	m.AppName = r.fstring()
+	m.BuildVersion = r.fstring()
+	m.LaunchExe = r.fstring()
+	r.fstring() // launch command
+	for n := r.u32(); n > 0 && r.err == nil; n-- {
+		r.fstring() // prerequisite ids
+	}
+	r.fstring() // prerequisite name
+	r.fstring() // prerequisite path
+	r.fstring() // prerequisite args
+	if dataVersion >= 1 {
+		r.fstring() // build id
+	}
+}
+
+// readChunkList validates the chunk data list. Verification works from the file
+// list alone, so the chunk details themselves are not kept.
+func (m *epicManifest) readChunkList(r *manifestReader) {
+	r.u8() // version
+	count := r.u32()
+	// GUID (16), hash (8), SHA-1 (20), group (1), window size (4) and file size (8), stored column by column.
+	r.skip(int64(count) * (16 + 8 + 20 + 1 + 4 + 8))
+}
+
+func (m *epicManifest) readFileList(r *manifestReader) {
+	r.u8() // version
+	count := int(r.u32())
+	if r.err != nil || count > r.r.Len() {
+		r.fail(errors.New("invalid file count"))
+		return
+	}
+	files := make([]manifestFile, count)
+	for i := range files {
+		files[i].Filename = r.fstring()
+	}
+	for range files {
+		r.fstring() // symlink target
+	}
+	for i := range files {
+		r.read(files[i].SHA1[:])
+	}
+	for range files {
+		r.u8() // flags
+	}
+	for range files {
+		for n := r.u32(); n > 0 && r.err == nil; n-- {
+			r.fstring() // install tags
+		}
+	}
+	for i := range files {
+		n := int(r.u32())
+		if r.err != nil || n > r.r.Len() {
+			r.fail(errors.New("invalid chunk part count"))
+			return
+		}
+		files[i].ChunkParts = make([]manifestChunkPart, n)
+		for j := range files[i].ChunkParts {
+			part := &files[i].ChunkParts[j]
+			r.section(func(r *manifestReader) {
+				for k := range part.GUID {
+					part.GUID[k] = r.u32()
+				}
+				part.Offset = r.u32()
+				part.Size = r.u32()
+			})
+		}
+	}
+	m.Files = files
+}
+
+// --- Binary Reader ---
+
+// manifestReader reads little-endian manifest fields, remembering the first error.
+type manifestReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (r *manifestReader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *manifestReader) read(p []byte) {
+	if r.err != nil {
+		return
+	}
+	if _, err := io.ReadFull(r.r, p); err != nil {
+		r.fail(io.ErrUnexpectedEOF)
+	}
+}
+
+func (r *manifestReader) u8() uint8 {
+	var b [1]byte
+	r.read(b[:])
+	return b[0]
+}
+
+func (r *manifestReader) u32() uint32 {
+	var b [4]byte
+	r.read(b[:])
+	return binary.LittleEndian.Uint32(b[:])
+}
+
+func (r *manifestReader) skip(n int64) {
+	if r.err != nil {
+		return
+	}
+	if n > int64(r.r.Len()) {
+		r.fail(io.ErrUnexpectedEOF)
+		return
+	}
+	r.r.Seek(n, io.SeekCurrent)
+}
+
+// fstring reads an Unreal FString: a signed length including the null terminator,
+// followed by ASCII bytes, or UTF-16 code units if the length is negative.
+func (r *manifestReader) fstring() string {
+	length := int32(r.u32())
+	switch {
+	case r.err != nil || length == 0:
+		return ""
+	case length > 0:
+		if int(length) > r.r.Len() {
+			r.fail(io.ErrUnexpectedEOF)
+			return ""
+		}
+		b := make([]byte, length)
+		r.read(b)
+		return string(bytes.TrimRight(b, "\x00"))
+	default:
+		n := -int(length)
+		if n*2 > r.r.Len() {
+			r.fail(io.ErrUnexpectedEOF)
+			return ""
+		}
+		b := make([]byte, n*2)
+		r.read(b)
+		units := make([]uint16, n)
+		for i := range units {
+			units[i] = binary.LittleEndian.Uint16(b[i*2:])
+		}
+		for len(units) > 0 && units[len(units)-1] == 0 {
+			units = units[:len(units)-1]
+		}
+		return string(utf16.Decode(units))
+	}
+}
+
+// section reads a size-prefixed structure and then skips to its end, so fields added
+// by newer manifest versions are ignored.
+func (r *manifestReader) section(read func(*manifestReader)) error {
+	start := r.r.Size() - int64(r.r.Len())
+	size := r.u32()
+	if r.err != nil {
+		return r.err
+	}
+	read(r)
+	if r.err != nil {
+		return r.err
+	}
+	end := start + int64(size)
+	if end < r.r.Size()-int64(r.r.Len()) || end > r.r.Size() {
+		r.fail(errors.New("section size does not match its contents"))
+		return r.err
+	}
+	r.r.Seek(end, io.SeekStart)
+	return nil
+}
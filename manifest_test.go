@@ -0,0 +1,195 @@
+// manifest_test.go
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// testManifestFile is a file to write into a synthetic manifest.
+type testManifestFile struct {
+	Name string
+	Data []byte
+}
+
+// manifestWriter writes the little-endian fields read by manifestReader.
+type manifestWriter struct {
+	bytes.Buffer
+}
+
+func (w *manifestWriter) u8(v uint8) { w.WriteByte(v) }
+
+func (w *manifestWriter) u32(v uint32) {
+	binary.Write(w, binary.LittleEndian, v)
+}
+
+func (w *manifestWriter) fstring(s string) {
+	if s == "" {
+		w.u32(0)
+		return
+	}
+	if isASCII(s) {
+		w.u32(uint32(len(s) + 1))
+		w.WriteString(s)
+		w.u8(0)
+		return
+	}
+	units := append(utf16.Encode([]rune(s)), 0)
+	w.u32(uint32(-int32(len(units))))
+	for _, u := range units {
+		binary.Write(w, binary.LittleEndian, u)
+	}
+}
+
+// section writes a size-prefixed structure.
+func (w *manifestWriter) section(write func(*manifestWriter)) {
+	var body manifestWriter
+	write(&body)
+	w.u32(uint32(4 + body.Len()))
+	w.Write(body.Bytes())
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// buildTestManifest writes a binary Epic manifest listing files, as the Epic
+// Games Launcher does, with each file stored in a single chunk part.
+func buildTestManifest(files []testManifestFile, compressed bool) []byte {
+	var body manifestWriter
+	body.section(func(w *manifestWriter) {
+		w.u8(1)   // data version
+		w.u32(18) // feature level
+		w.u8(0)   // is file data
+		w.u32(0)  // app id
+		w.fstring("Sugar")
+		w.fstring("++Fortnite+Release-Cert-CL-1234")
+		w.fstring("Binaries/Win64/RocketLeague.exe")
+		w.fstring("")
+		w.u32(0) // prerequisite ids
+		w.fstring("")
+		w.fstring("")
+		w.fstring("")
+		w.fstring("build-id")
+	})
+	body.section(func(w *manifestWriter) {
+		w.u8(0) // version
+		w.u32(0)
+	})
+	body.section(func(w *manifestWriter) {
+		w.u8(0) // version
+		w.u32(uint32(len(files)))
+		for _, f := range files {
+			w.fstring(f.Name)
+		}
+		for range files {
+			w.fstring("") // symlink target
+		}
+		for _, f := range files {
+			sum := sha1.Sum(f.Data)
+			w.Write(sum[:])
+		}
+		for range files {
+			w.u8(0) // flags
+		}
+		for range files {
+			w.u32(0) // install tags
+		}
+		for i, f := range files {
+			w.u32(1)
+			w.section(func(w *manifestWriter) {
+				for _, v := range [4]uint32{uint32(i), 1, 2, 3} {
+					w.u32(v)
+				}
+				w.u32(0)
+				w.u32(uint32(len(f.Data)))
+			})
+		}
+	})
+
+	stored := body.Bytes()
+	var flags uint8
+	if compressed {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write(body.Bytes())
+		zw.Close()
+		stored = buf.Bytes()
+		flags = manifestFlagCompressed
+	}
+
+	var out manifestWriter
+	out.u32(manifestMagic)
+	out.u32(41) // header size
+	out.u32(uint32(body.Len()))
+	out.u32(uint32(len(stored)))
+	sum := sha1.Sum(body.Bytes())
+	out.Write(sum[:])
+	out.u8(flags)
+	out.u32(18) // version
+	out.Write(stored)
+	return out.Bytes()
+}
+
+var testGameFiles = []testManifestFile{
+	{`Binaries\Win64\RocketLeague.exe`, []byte("game executable")},
+	{"TAGame/CookedPCConsole/Park_P.upk", []byte("stadium")},
+	{"Engine/Content/Ünïcode.txt", []byte("unicode name")},
+	{"Engine/Config/Empty.ini", nil},
+}
+
+func TestParseEpicManifest(t *testing.T) {
+	for _, compressed := range []bool{false, true} {
+		m, err := parseEpicManifest(buildTestManifest(testGameFiles, compressed))
+		if err != nil {
+			t.Fatalf("compressed=%v: %v", compressed, err)
+		}
+		if m.AppName != "Sugar" || m.BuildVersion != "++Fortnite+Release-Cert-CL-1234" || m.LaunchExe != "Binaries/Win64/RocketLeague.exe" {
+			t.Errorf("compressed=%v: metadata = %q, %q, %q", compressed, m.AppName, m.BuildVersion, m.LaunchExe)
+		}
+		if len(m.Files) != len(testGameFiles) {
+			t.Fatalf("compressed=%v: got %d files, want %d", compressed, len(m.Files), len(testGameFiles))
+		}
+		for i, f := range m.Files {
+			want := testGameFiles[i]
+			if f.Filename != want.Name || f.SHA1 != sha1.Sum(want.Data) || f.Size() != int64(len(want.Data)) {
+				t.Errorf("compressed=%v: file %d = %q (%d bytes), want %q (%d bytes)", compressed, i, f.Filename, f.Size(), want.Name, len(want.Data))
+			}
+		}
+	}
+}
+
+func TestParseEpicManifestErrors(t *testing.T) {
+	valid := buildTestManifest(testGameFiles, false)
+	corrupt := func(edit func([]byte) []byte) []byte {
+		return edit(bytes.Clone(valid))
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "manifest header"},
+		{"JSON", []byte(`{"ManifestFileVersion": "013000000000"}`), "JSON manifests are not supported"},
+		{"wrong magic", corrupt(func(b []byte) []byte { b[0]++; return b }), "not an Epic manifest"},
+		{"encrypted", corrupt(func(b []byte) []byte { b[36] = manifestFlagEncrypted; return b }), "encrypted"},
+		{"truncated body", valid[:len(valid)-10], "expected"},
+		{"hash mismatch", corrupt(func(b []byte) []byte { b[len(b)-1]++; return b }), "hash mismatch"},
+	}
+	for _, tt := range tests {
+		_, err := parseEpicManifest(tt.data)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want it to contain %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,549 @@
+// process.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// instanceLockFileName records the running instance and the processes it launched.
+	instanceLockFileName = "slipstream.lock"
+	// stopRequestFileName tells the running instance that 'Slipstream stop' ended its
+	// game, where it can't be signalled (Windows), so the exit isn't taken for a crash.
+	stopRequestFileName = "slipstream.stop"
+
+	defaultStopGracePeriod = 10 // seconds
+
+	// Policies for Config.AlreadyRunning.
+	alreadyRunningRefuse = "refuse"
+	alreadyRunningWait   = "wait"
+	alreadyRunningExit   = "exit"
+)
+
+// rocketLeagueProcessNames are the executables that show the game is running.
+var rocketLeagueProcessNames = []string{"RocketLeague.exe", "RocketLeague_EAC.exe"}
+
+// processInfo describes an entry in the system process table.
+type processInfo struct {
+	PID       int
+	ParentPID int
+	Name      string
+}
+
+// canListProcesses reports whether the process table can be read on this system.
+func canListProcesses() bool {
+	procs, err := listProcesses()
+	return err == nil && len(procs) > 0
+}
+
+// findProcesses returns the PIDs of running processes with any of the given executable names.
+func findProcesses(names ...string) []int {
+	procs, err := listProcesses()
+	if err != nil {
+		log.Printf("Warning: could not list running processes: %v", err)
+		return nil
+	}
+	var pids []int
+	for _, p := range procs {
+		if p.PID == os.Getpid() {
+			continue
+		}
+		for _, name := range names {
+			if strings.EqualFold(p.Name, name) {
+				pids = append(pids, p.PID)
+				break
+			}
+		}
+	}
+	return pids
+}
+
+// --- Launched Process Tracking ---
+
+// trackedProcess is a process started by Slipstream whose exit we observe.
+type trackedProcess struct {
+	name         string
+	cmd          *exec.Cmd // nil for adopted processes
+	procID       int
+	done         chan struct{}
+	err          error
+	stopWithGame bool // Companion that is stopped when the game exits.
+}
+
+// startTracked starts cmd in its own process group and reaps it in the background.
+func startTracked(name string, cmd *exec.Cmd) (*trackedProcess, error) {
+	prepareProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p := &trackedProcess{name: name, cmd: cmd, procID: cmd.Process.Pid, done: make(chan struct{})}
+	go func() {
+		p.err = cmd.Wait()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// adoptProcess tracks a running process Slipstream did not start. Its exit is found
+// by polling, and its exit code is unknown.
+func adoptProcess(name string, pid int) *trackedProcess {
+	p := &trackedProcess{name: name, procID: pid, done: make(chan struct{})}
+	go func() {
+		for processExists(pid) {
+			time.Sleep(time.Second)
+		}
+		close(p.done)
+	}()
+	return p
+}
+
+func (p *trackedProcess) pid() int {
+	return p.procID
+}
+
+// exitState returns how the process exited, or nil if it is running or was adopted.
+func (p *trackedProcess) exitState() *os.ProcessState {
+	if p.cmd == nil || !p.exited() {
+		return nil
+	}
+	return p.cmd.ProcessState
+}
+
+func (p *trackedProcess) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// gameSession holds the game and every companion process started alongside it.
+// Companions may be added, and the game process replaced, from other goroutines
+// while the game runs.
+type gameSession struct {
+	game           *trackedProcess
+	hookCtx        hookContext
+	startedAt      time.Time
+	ended          chan struct{} // Closed when the game, including an adopted game process, has exited
+	launchLog      string        // Launch.log path, if known
+	launchLogStart logPosition   // End of Launch.log before the game started
+	diagnostics    chan struct{} // Closed when Launch.log watching ends; nil if not watching
+	mu             sync.Mutex
+	companions     []*trackedProcess
+}
+
+// newGameSession starts a session for a launched game and follows it in the background.
+func newGameSession(game *trackedProcess, hookCtx hookContext) *gameSession {
+	s := &gameSession{game: game, hookCtx: hookCtx, startedAt: time.Now(), ended: make(chan struct{})}
+	go s.follow()
+	return s
+}
+
+// follow closes s.ended once the game has exited. The EAC bootstrapper
+// (RocketLeague_EAC.exe) can exit as soon as it has started RocketLeague.exe; the
+// game process is then adopted, so the session lasts as long as the game itself.
+func (s *gameSession) follow() {
+	game := s.currentGame()
+	<-game.done
+	if canListProcesses() {
+		for _, pid := range findProcesses("RocketLeague.exe") {
+			if pid == game.pid() {
+				continue
+			}
+			log.Printf("%s exited, following the game process (PID %d).", game.name, pid)
+			game = adoptProcess(game.name, pid)
+			s.mu.Lock()
+			s.game = game
+			s.hookCtx.GamePID = pid
+			s.mu.Unlock()
+			if err := writeInstanceLock(s); err != nil {
+				log.Printf("Warning: could not update lock file: %v", err)
+			}
+			<-game.done
+			break
+		}
+	}
+	close(s.ended)
+}
+
+// currentGame returns the game process, which changes if the game is adopted.
+func (s *gameSession) currentGame() *trackedProcess {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.game
+}
+
+func (s *gameSession) processes() []*trackedProcess {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*trackedProcess{s.game}, s.companions...)
+}
+
+// addCompanion tracks a started companion and records it in the lock file.
+func (s *gameSession) addCompanion(p *trackedProcess) {
+	s.mu.Lock()
+	s.companions = append(s.companions, p)
+	s.mu.Unlock()
+	if err := writeInstanceLock(s); err != nil {
+		log.Printf("Warning: could not update lock file: %v", err)
+	}
+}
+
+// supervise blocks until the game exits or a stop signal arrives.
+// On a signal the whole launched process tree is stopped, and true is returned.
+func (s *gameSession) supervise(grace time.Duration) bool {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	select {
+	case <-s.ended:
+		log.Printf("Rocket League exited (%v).", s.currentGame().err)
+		return false
+	case sig := <-sigCh:
+		log.Printf("Received %v, stopping Rocket League and companion processes...", sig)
+		s.stop(grace)
+		return true
+	}
+}
+
+// stop asks every launched process to terminate, then kills whatever is left after the grace period.
+func (s *gameSession) stop(grace time.Duration) {
+	stopTracked(s.processes(), grace)
+}
+
+func stopTracked(procs []*trackedProcess, grace time.Duration) {
+	for _, p := range procs {
+		if p.exited() {
+			continue
+		}
+		if err := terminateProcessTree(p.pid()); err != nil {
+			log.Printf("Warning: could not ask %s (PID %d) to stop: %v", p.name, p.pid(), err)
+		}
+	}
+
+	deadline := time.After(grace)
+	for _, p := range procs {
+		select {
+		case <-p.done:
+		case <-deadline:
+		}
+		if !p.exited() {
+			log.Printf("%s (PID %d) did not stop within %v, killing it.", p.name, p.pid(), grace)
+			if err := killProcessTree(p.pid()); err != nil {
+				log.Printf("Warning: could not kill %s (PID %d): %v", p.name, p.pid(), err)
+			}
+			select {
+			case <-p.done:
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}
+}
+
+// stopCompanionsWithGame stops the companions configured to exit along with the game.
+func (s *gameSession) stopCompanionsWithGame(grace time.Duration) {
+	s.mu.Lock()
+	var procs []*trackedProcess
+	for _, p := range s.companions {
+		if p.stopWithGame && !p.exited() {
+			procs = append(procs, p)
+		}
+	}
+	s.mu.Unlock()
+	stopTracked(procs, grace)
+}
+
+func stopGracePeriod(cfg Config) time.Duration {
+	if cfg.StopGracePeriod <= 0 {
+		return defaultStopGracePeriod * time.Second
+	}
+	return time.Duration(cfg.StopGracePeriod) * time.Second
+}
+
+// --- Instance Lock File ---
+
+// instanceLock is the content of the lock file written while a session is running.
+type instanceLock struct {
+	PID           int       `json:"pid"`
+	GamePID       int       `json:"game_pid,omitempty"`
+	CompanionPIDs []int     `json:"companion_pids,omitempty"`
+	Config        string    `json:"config"`
+	StartedAt     time.Time `json:"started_at"`
+}
+
+func instanceLockPath() string {
+	return filepath.Join(getExecutableDir(), instanceLockFileName)
+}
+
+func newInstanceLock() instanceLock {
+	return instanceLock{
+		PID:       os.Getpid(),
+		Config:    getConfigFileName(),
+		StartedAt: time.Now(),
+	}
+}
+
+// writeInstanceLock records the session's processes in the lock file held by this instance.
+func writeInstanceLock(s *gameSession) error {
+	lock := newInstanceLock()
+	game := s.currentGame()
+	for _, p := range s.processes() {
+		if p == game {
+			lock.GamePID = p.pid()
+		} else {
+			lock.CompanionPIDs = append(lock.CompanionPIDs, p.pid())
+		}
+	}
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(instanceLockPath(), data, 0644)
+}
+
+// tryAcquireInstanceLock creates the lock file for this process. If another live
+// instance already holds it, that instance's lock is returned with ok set to false.
+// Locks left behind by instances that no longer exist are replaced.
+func tryAcquireInstanceLock() (other instanceLock, ok bool, err error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(instanceLockPath(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			defer f.Close()
+			data, err := json.MarshalIndent(newInstanceLock(), "", "  ")
+			if err != nil {
+				return other, false, err
+			}
+			_, err = f.Write(data)
+			return other, err == nil, err
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return other, false, err
+		}
+
+		existing, readErr := readInstanceLock()
+		if readErr == nil && existing.PID != os.Getpid() && existing.owns(existing.PID) {
+			return existing, false, nil
+		}
+		log.Println("Removing stale lock file left by a previous instance.")
+		os.Remove(stopRequestPath())
+		if err := os.Remove(instanceLockPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return other, false, err
+		}
+	}
+	return other, false, errors.New("lock file keeps reappearing")
+}
+
+// owns reports whether pid is still the process recorded in the lock. A process
+// that started after the lock was last written has only been given a reused PID.
+func (lock instanceLock) owns(pid int) bool {
+	if !processExists(pid) {
+		return false
+	}
+	started, ok := processStartTime(pid)
+	// Start times are rounded to the second on some systems.
+	return !ok || !started.After(lock.StartedAt.Add(time.Second))
+}
+
+func readInstanceLock() (instanceLock, error) {
+	var lock instanceLock
+	data, err := os.ReadFile(instanceLockPath())
+	if err != nil {
+		return lock, err
+	}
+	err = json.Unmarshal(data, &lock)
+	return lock, err
+}
+
+// removeInstanceLock deletes the lock file, but only if it still belongs to this process.
+func removeInstanceLock() {
+	lock, err := readInstanceLock()
+	if err != nil || lock.PID != os.Getpid() {
+		return
+	}
+	if stopRequested() {
+		os.Remove(stopRequestPath())
+	}
+	if err := os.Remove(instanceLockPath()); err != nil {
+		log.Printf("Warning: could not remove lock file: %v", err)
+	}
+}
+
+func stopRequestPath() string {
+	return filepath.Join(getExecutableDir(), stopRequestFileName)
+}
+
+// requestStop records that the instance holding lock is being stopped.
+func requestStop(lock instanceLock) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(stopRequestPath(), data, 0644)
+}
+
+// stopRequested reports whether 'Slipstream stop' was run against this instance.
+func stopRequested() bool {
+	var lock instanceLock
+	data, err := os.ReadFile(stopRequestPath())
+	if err != nil || json.Unmarshal(data, &lock) != nil {
+		return false
+	}
+	return lock.PID == os.Getpid()
+}
+
+// --- Single-Instance Guard ---
+
+// claimSingleInstance makes sure neither Rocket League nor another Slipstream instance
+// is running before a launch spends a token rotation. What happens when one is found
+// depends on Config.AlreadyRunning. It returns false if this instance should exit
+// without launching; on success the caller holds the lock file.
+func claimSingleInstance(cfg Config) (bool, error) {
+	waiting := false
+	for {
+		other, ok, err := tryAcquireInstanceLock()
+		if err != nil {
+			return false, err
+		}
+
+		var waitPIDs []int
+		var reason string
+		if ok {
+			gamePIDs := findProcesses(rocketLeagueProcessNames...)
+			if len(gamePIDs) == 0 {
+				return true, nil
+			}
+			removeInstanceLock()
+			waitPIDs = gamePIDs
+			reason = fmt.Sprintf("Rocket League is already running (PID %d).", gamePIDs[0])
+		} else {
+			waitPIDs = []int{other.PID}
+			reason = fmt.Sprintf("Another Slipstream instance is already running (PID %d, config '%s').", other.PID, other.Config)
+		}
+
+		switch strings.ToLower(cfg.AlreadyRunning) {
+		case alreadyRunningExit:
+			log.Printf("%s Exiting without launching.", reason)
+			return false, nil
+		case alreadyRunningWait:
+			// Check again every minute, in case the PID now belongs to another process.
+			if waiting {
+				log.Println("Still waiting for the running instance to exit...")
+			} else {
+				log.Printf("%s Waiting for it to exit...", reason)
+				waiting = true
+			}
+			waitForExit(waitPIDs, time.Minute)
+		default: // alreadyRunningRefuse
+			showError("Already Running", reason+"\n\n"+
+				"Close the game before launching it again. You can also run 'Slipstream stop' to close it, "+
+				"or set \"already_running\" in 'config.json' to \"wait\" or \"exit\" to change this behaviour.")
+			return false, nil
+		}
+	}
+}
+
+// --- Stop Command ---
+
+// runStopCommand stops the running instance recorded in the lock file.
+// The instance is signalled so it can clean up itself; if that is not possible,
+// the recorded processes are stopped directly.
+func runStopCommand() error {
+	lock, err := readInstanceLock()
+	if errors.Is(err, os.ErrNotExist) {
+		log.Println("No running Slipstream instance found.")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read lock file: %w", err)
+	}
+
+	cfg, _ := readConfigFile()
+	grace := stopGracePeriod(cfg)
+
+	if lock.owns(lock.PID) {
+		if err := requestStop(lock); err != nil {
+			log.Printf("Warning: could not record the stop request: %v", err)
+		}
+		log.Printf("Asking Slipstream instance (PID %d) to stop...", lock.PID)
+		if err := signalInstance(lock.PID); err == nil {
+			// Allow the instance its own grace period plus a little time to exit.
+			if waitForExit([]int{lock.PID}, grace+5*time.Second) {
+				return nil
+			}
+			log.Printf("Slipstream instance (PID %d) did not exit in time.", lock.PID)
+		} else {
+			log.Printf("Could not signal Slipstream instance: %v. Stopping its processes directly.", err)
+		}
+	}
+
+	var pids []int
+	for _, pid := range append([]int{lock.GamePID}, lock.CompanionPIDs...) {
+		if pid > 0 && lock.owns(pid) {
+			pids = append(pids, pid)
+		}
+	}
+	stopProcesses(pids, grace)
+	if !lock.owns(lock.PID) {
+		os.Remove(instanceLockPath())
+	}
+	return nil
+}
+
+// stopProcesses terminates the given process trees and kills any still alive after the grace period.
+func stopProcesses(pids []int, grace time.Duration) {
+	var alive []int
+	for _, pid := range pids {
+		if pid <= 0 || !processExists(pid) {
+			continue
+		}
+		alive = append(alive, pid)
+		if err := terminateProcessTree(pid); err != nil {
+			log.Printf("Warning: could not ask PID %d to stop: %v", pid, err)
+		}
+	}
+	if waitForExit(alive, grace) {
+		return
+	}
+	for _, pid := range alive {
+		if processExists(pid) {
+			log.Printf("PID %d did not stop within %v, killing it.", pid, grace)
+			if err := killProcessTree(pid); err != nil {
+				log.Printf("Warning: could not kill PID %d: %v", pid, err)
+			}
+		}
+	}
+}
+
+// waitForExit polls until none of the given processes exist, or the timeout passes.
+func waitForExit(pids []int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		running := false
+		for _, pid := range pids {
+			if processExists(pid) {
+				running = true
+				break
+			}
+		}
+		if !running {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
@@ -0,0 +1,180 @@
+//go:build !windows
+
+// process_unix.go
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// prepareProcessGroup starts the command as the leader of a new process group,
+// so that signals can be forwarded to everything it spawns (e.g. Wine children).
+func prepareProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// terminateProcessTree sends SIGTERM to the process group led by pid, or to pid alone.
+func terminateProcessTree(pid int) error {
+	return signalTree(pid, syscall.SIGTERM)
+}
+
+// killProcessTree sends SIGKILL to the process group led by pid, or to pid alone.
+func killProcessTree(pid int) error {
+	return signalTree(pid, syscall.SIGKILL)
+}
+
+func signalTree(pid int, sig syscall.Signal) error {
+	if err := syscall.Kill(-pid, sig); err == nil {
+		return nil
+	}
+	return syscall.Kill(pid, sig)
+}
+
+// signalInstance asks another Slipstream instance to stop its session.
+func signalInstance(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
+
+func processExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// clockTicks is the unit of process start times in /proc (USER_HZ), which is 100 on
+// every Linux architecture Slipstream runs on.
+const clockTicks = 100
+
+// processStartTime reads when the process started from /proc. It reports false on
+// systems without procfs.
+func processStartTime(pid int) (time.Time, bool) {
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return time.Time{}, false
+	}
+	// starttime is the 22nd field; the fields after "(comm)" start with the 3rd.
+	end := bytes.LastIndexByte(stat, ')')
+	fields := strings.Fields(string(stat[end+1:]))
+	if end < 0 || len(fields) < 20 {
+		return time.Time{}, false
+	}
+	ticks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	boot, ok := bootTime()
+	if !ok {
+		return time.Time{}, false
+	}
+	return boot.Add(time.Duration(ticks) * time.Second / clockTicks), true
+}
+
+// bootTime reads the system boot time from the btime line of /proc/stat.
+func bootTime() (time.Time, bool) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}, false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "btime "); ok {
+			if secs, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+				return time.Unix(secs, 0), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// listProcesses reads the process table from /proc. It returns an empty list on
+// systems without procfs, such as macOS.
+func listProcesses() ([]processInfo, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var procs []processInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "stat"))
+		if err != nil {
+			continue // Process exited while we were scanning.
+		}
+		info := processInfo{PID: pid}
+		// Format: pid (comm) state ppid ...; comm may itself contain spaces or parentheses.
+		if open, end := bytes.IndexByte(stat, '('), bytes.LastIndexByte(stat, ')'); open >= 0 && end > open {
+			info.Name = string(stat[open+1 : end])
+			if fields := strings.Fields(string(stat[end+1:])); len(fields) > 1 {
+				info.ParentPID, _ = strconv.Atoi(fields[1])
+			}
+		}
+		// Prefer the full executable name from the command line; comm is truncated to
+		// 15 characters, and Wine processes show their Windows path here.
+		if cmdline, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "cmdline")); err == nil {
+			if argv0, _, _ := bytes.Cut(cmdline, []byte{0}); len(argv0) > 0 {
+				info.Name = windowsBase(string(argv0))
+			}
+		}
+		procs = append(procs, info)
+	}
+	return procs, nil
+}
+
+// windowsBase returns the last element of a path that may use either separator.
+func windowsBase(path string) string {
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+// niceLevels maps Config.Priority values to nice increments.
+var niceLevels = map[string]int{
+	priorityLow:         10,
+	priorityBelowNormal: 5,
+	priorityAboveNormal: -5,
+	priorityHigh:        -10,
+}
+
+// setPriority runs cmd through nice. Raising the priority needs privileges; without
+// them nice prints a warning and starts the game at normal priority.
+func setPriority(cmd *exec.Cmd, priority string) *exec.Cmd {
+	return wrapCommand(cmd, []string{"nice", "-n", strconv.Itoa(niceLevels[priority])})
+}
+
+// setCommandLine is a no-op outside Windows; arguments are passed as an argv array.
+func setCommandLine(cmd *exec.Cmd) {}
+
+// classifyExitState tells a crash from a kill for a process ended by a signal.
+// Processes that exited normally are classified by their exit code.
+func classifyExitState(state *os.ProcessState) (exitKind, string) {
+	ws, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return classifyExitCode(state.ExitCode())
+	}
+	switch ws.Signal() {
+	case syscall.SIGKILL, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP:
+		return exitKilled, "signal: " + ws.Signal().String()
+	default:
+		return exitCrash, "signal: " + ws.Signal().String()
+	}
+}
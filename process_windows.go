@@ -0,0 +1,128 @@
+//go:build windows
+
+// process_windows.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// stillActive is the exit code GetExitCodeProcess reports for a running process.
+const stillActive = 259
+
+// prepareProcessGroup is a no-op on Windows; taskkill /T walks the process tree instead.
+func prepareProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcessTree asks the process and its children to close their windows.
+func terminateProcessTree(pid int) error {
+	return exec.Command("taskkill", "/PID", strconv.Itoa(pid), "/T").Run()
+}
+
+// killProcessTree forcefully ends the process and its children.
+func killProcessTree(pid int) error {
+	return exec.Command("taskkill", "/PID", strconv.Itoa(pid), "/T", "/F").Run()
+}
+
+// signalInstance is not supported on Windows, which has no SIGTERM for other processes.
+func signalInstance(pid int) error {
+	return errors.New("signalling another process is not supported on Windows")
+}
+
+func processExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(h)
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+// processStartTime returns the creation time of the process.
+func processStartTime(pid int) (time.Time, bool) {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer windows.CloseHandle(h)
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, creation.Nanoseconds()), true
+}
+
+// listProcesses takes a snapshot of the running processes.
+func listProcesses() ([]processInfo, error) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(snapshot)
+
+	var procs []processInfo
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	for err = windows.Process32First(snapshot, &entry); err == nil; err = windows.Process32Next(snapshot, &entry) {
+		procs = append(procs, processInfo{
+			PID:       int(entry.ProcessID),
+			ParentPID: int(entry.ParentProcessID),
+			Name:      windows.UTF16ToString(entry.ExeFile[:]),
+		})
+	}
+	if !errors.Is(err, windows.ERROR_NO_MORE_FILES) {
+		return procs, err
+	}
+	return procs, nil
+}
+
+// priorityClasses maps Config.Priority values to process creation flags.
+var priorityClasses = map[string]uint32{
+	priorityLow:         windows.IDLE_PRIORITY_CLASS,
+	priorityBelowNormal: windows.BELOW_NORMAL_PRIORITY_CLASS,
+	priorityAboveNormal: windows.ABOVE_NORMAL_PRIORITY_CLASS,
+	priorityHigh:        windows.HIGH_PRIORITY_CLASS,
+}
+
+// setPriority starts cmd in the matching Windows priority class.
+func setPriority(cmd *exec.Cmd, priority string) *exec.Cmd {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= priorityClasses[priority]
+	return cmd
+}
+
+// setCommandLine renders the command line EGL-style instead of relying on Go's
+// default escaping, which would turn -epicusername="Name" into "-epicusername=\"Name\"".
+func setCommandLine(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CmdLine = windowsCommandLine(cmd.Args)
+}
+
+// classifyExitState classifies a process exit by its exit code. Windows reports
+// unhandled exceptions as NTSTATUS error codes (0xC0000000 and up).
+func classifyExitState(state *os.ProcessState) (exitKind, string) {
+	code := uint32(state.ExitCode())
+	if code >= 0xC0000000 {
+		return exitCrash, fmt.Sprintf("exception 0x%08X", code)
+	}
+	return classifyExitCode(state.ExitCode())
+}
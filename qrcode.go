@@ -0,0 +1,476 @@
+// qrcode.go
+package main
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// A minimal QR code encoder (ISO/IEC 18004) for showing the login URL: byte mode,
+// error correction level M, versions 1 to 40, with automatic mask selection.
+
+const qrQuietZone = 4 // Modules of light border the standard requires around the code
+
+// qrBlocks is the error correction layout of each version at level M: error
+// correction codewords per block, then the number of blocks and data codewords per
+// block in the first and second group.
+var qrBlocks = [41][5]int{
+	{},
+	{10, 1, 16, 0, 0}, {16, 1, 28, 0, 0}, {26, 1, 44, 0, 0}, {18, 2, 32, 0, 0}, {24, 2, 43, 0, 0},
+	{16, 4, 27, 0, 0}, {18, 4, 31, 0, 0}, {22, 2, 38, 2, 39}, {22, 3, 36, 2, 37}, {26, 4, 43, 1, 44},
+	{30, 1, 50, 4, 51}, {22, 6, 36, 2, 37}, {22, 8, 37, 1, 38}, {24, 4, 40, 5, 41}, {24, 5, 41, 5, 42},
+	{28, 7, 45, 3, 46}, {28, 10, 46, 1, 47}, {26, 9, 43, 4, 44}, {26, 3, 44, 11, 45}, {26, 3, 41, 13, 42},
+	{26, 17, 42, 0, 0}, {28, 17, 46, 0, 0}, {28, 4, 47, 14, 48}, {28, 6, 45, 14, 46}, {28, 8, 47, 13, 48},
+	{28, 19, 46, 4, 47}, {28, 22, 45, 3, 46}, {28, 3, 45, 23, 46}, {28, 21, 45, 7, 46}, {28, 19, 47, 10, 48},
+	{28, 2, 46, 29, 47}, {28, 10, 46, 23, 47}, {28, 14, 46, 21, 47}, {28, 14, 46, 23, 47}, {28, 12, 47, 26, 48},
+	{28, 6, 47, 34, 48}, {28, 29, 46, 14, 47}, {28, 13, 46, 32, 47}, {28, 40, 47, 7, 48}, {28, 18, 47, 31, 48},
+}
+
+// qrCode is an encoded QR code; modules[y][x] is true for dark modules.
+type qrCode struct {
+	size     int
+	modules  [][]bool
+	function [][]bool // Finder, timing, alignment, format and version modules
+}
+
+// encodeQR encodes data as a QR code in the smallest version that fits.
+func encodeQR(data string) (*qrCode, error) {
+	version := 0
+	for v := 1; v <= 40; v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= 8*qrDataCodewords(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, errors.New("too much data for a QR code")
+	}
+
+	q := &qrCode{size: version*4 + 17}
+	q.modules = make([][]bool, q.size)
+	q.function = make([][]bool, q.size)
+	for y := range q.modules {
+		q.modules[y] = make([]bool, q.size)
+		q.function[y] = make([]bool, q.size)
+	}
+	q.drawFunctionPatterns(version)
+	q.drawCodewords(qrAddErrorCorrection(version, qrDataBits(version, data)))
+
+	// Use the mask with the lowest penalty, as the standard requires.
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		q.applyMask(mask) // Masking twice undoes it.
+	}
+	q.applyMask(best)
+	q.drawFormatBits(best)
+	return q, nil
+}
+
+func qrDataCodewords(version int) int {
+	b := qrBlocks[version]
+	return b[1]*b[2] + b[3]*b[4]
+}
+
+// qrDataBits returns the data codewords: byte mode header, data, terminator and padding.
+func qrDataBits(version int, data string) []byte {
+	var bits []bool
+	appendBits := func(value, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, value>>i&1 == 1)
+		}
+	}
+	appendBits(0b0100, 4) // Byte mode
+	if version < 10 {
+		appendBits(len(data), 8)
+	} else {
+		appendBits(len(data), 16)
+	}
+	for i := 0; i < len(data); i++ {
+		appendBits(int(data[i]), 8)
+	}
+
+	capacity := 8 * qrDataCodewords(version)
+	appendBits(0, min(4, capacity-len(bits)))
+	appendBits(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		appendBits(pad, 8)
+	}
+
+	codewords := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			codewords[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return codewords
+}
+
+// qrAddErrorCorrection splits the data into blocks, adds Reed-Solomon error
+// correction to each, and interleaves the result.
+func qrAddErrorCorrection(version int, data []byte) []byte {
+	b := qrBlocks[version]
+	eccLen := b[0]
+	var blocks, eccs [][]byte
+	for i := 0; i < b[1]+b[3]; i++ {
+		n := b[2]
+		if i >= b[1] {
+			n = b[4]
+		}
+		blocks = append(blocks, data[:n])
+		eccs = append(eccs, reedSolomon(data[:n], eccLen))
+		data = data[n:]
+	}
+
+	var result []byte
+	for i := 0; i < max(b[2], b[4]); i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < eccLen; i++ {
+		for _, ecc := range eccs {
+			result = append(result, ecc[i])
+		}
+	}
+	return result
+}
+
+// reedSolomon returns n error correction codewords for data over GF(2^8) with the
+// QR code polynomial x^8 + x^4 + x^3 + x^2 + 1.
+func reedSolomon(data []byte, n int) []byte {
+	// Generator polynomial (x - a^0)(x - a^1)...(x - a^(n-1)), highest term dropped.
+	gen := make([]byte, n)
+	gen[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			gen[j] = gfMultiply(gen[j], root)
+			if j+1 < n {
+				gen[j] ^= gen[j+1]
+			}
+		}
+		root = gfMultiply(root, 2)
+	}
+
+	rem := make([]byte, n)
+	for _, d := range data {
+		factor := d ^ rem[0]
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for i := range rem {
+			rem[i] ^= gfMultiply(gen[i], factor)
+		}
+	}
+	return rem
+}
+
+func gfMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+func (q *qrCode) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+func (q *qrCode) drawFunctionPatterns(version int) {
+	// Timing patterns
+	for i := 0; i < q.size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	// Finder patterns with their separators
+	for _, c := range [][2]int{{3, 3}, {q.size - 4, 3}, {3, q.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x >= 0 && x < q.size && y >= 0 && y < q.size {
+					dist := max(abs(dx), abs(dy))
+					q.set(x, y, dist != 2 && dist != 4)
+				}
+			}
+		}
+	}
+	// Alignment patterns, except where they would overlap the finder patterns
+	pos := qrAlignmentPositions(version)
+	for i, y := range pos {
+		for j, x := range pos {
+			if (i == 0 && j == 0) || (i == 0 && j == len(pos)-1) || (i == len(pos)-1 && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	// Reserve the format areas; drawFormatBits fills them in.
+	q.drawFormatBits(0)
+	// Version information
+	if version >= 7 {
+		bits := qrVersionBits(version)
+		for i := 0; i < 18; i++ {
+			dark := bits>>i&1 == 1
+			a, b := q.size-11+i%3, i/3
+			q.set(a, b, dark)
+			q.set(b, a, dark)
+		}
+	}
+}
+
+// qrAlignmentPositions returns the row and column centers of the alignment patterns.
+func qrAlignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := 26
+	if version != 32 {
+		step = (version*4 + n*2 + 1) / (n*2 - 2) * 2
+	}
+	pos := make([]int, n)
+	pos[0] = 6
+	for i, p := n-1, version*4+10; i >= 1; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+// qrVersionBits returns the 18-bit version information: the version and its
+// BCH(18,6) error correction.
+func qrVersionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	return version<<12 | rem
+}
+
+// qrFormatBits returns the 15-bit format information for level M and the mask: the
+// BCH(15,5) code, XORed with the standard's mask pattern.
+func qrFormatBits(mask int) int {
+	data := 0b00<<3 | mask // Level M
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawFormatBits draws the error correction level (M) and mask, in both copies.
+func (q *qrCode) drawFormatBits(mask int) {
+	bits := qrFormatBits(mask)
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true) // Always dark
+}
+
+// drawCodewords places the codewords in the zigzag order of the standard: two-module
+// wide columns from the right, alternately upwards and downwards.
+func (q *qrCode) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // Skip the vertical timing pattern.
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert
+				}
+				if !q.function[y][x] && i < len(data)*8 {
+					q.modules[y][x] = data[i/8]>>(7-i%8)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (q *qrCode) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !q.function[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the code is to scan, following the four rules of the
+// standard: long runs, 2x2 blocks, finder-like patterns and dark/light balance.
+func (q *qrCode) penalty() int {
+	result := 0
+	finderLike := [][]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+	line := make([]bool, q.size)
+	for _, vertical := range []bool{false, true} {
+		for a := 0; a < q.size; a++ {
+			for b := 0; b < q.size; b++ {
+				if vertical {
+					line[b] = q.modules[b][a]
+				} else {
+					line[b] = q.modules[a][b]
+				}
+			}
+			run := 1
+			for b := 1; b <= q.size; b++ {
+				if b < q.size && line[b] == line[b-1] {
+					run++
+					continue
+				}
+				if run >= 5 {
+					result += 3 + run - 5
+				}
+				run = 1
+			}
+			for b := 0; b+11 <= q.size; b++ {
+				for _, pattern := range finderLike {
+					if slicesEqual(line[b:b+11], pattern) {
+						result += 40
+					}
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size {
+				c := q.modules[y][x]
+				if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+					result += 3
+				}
+			}
+		}
+	}
+	total := q.size * q.size
+	result += ((abs(dark*20-total*10)+total-1)/total - 1) * 10
+	return result
+}
+
+func slicesEqual(a, b []bool) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// dark reports whether the module at x, y is dark; the quiet zone around the code is light.
+func (q *qrCode) dark(x, y int) bool {
+	return x >= 0 && y >= 0 && x < q.size && y < q.size && q.modules[y][x]
+}
+
+// terminalArt draws the code with Unicode half blocks, two rows per line. Light
+// modules are drawn as blocks, so the code reads correctly on the usual dark
+// terminal background.
+func (q *qrCode) terminalArt() string {
+	var b strings.Builder
+	for y := -qrQuietZone; y < q.size+qrQuietZone; y += 2 {
+		for x := -qrQuietZone; x < q.size+qrQuietZone; x++ {
+			top, bottom := !q.dark(x, y), !q.dark(x, y+1)
+			if y+1 >= q.size+qrQuietZone {
+				bottom = false
+			}
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// writePNG writes the code as a black-on-white PNG with scale pixels per module.
+func (q *qrCode) writePNG(w io.Writer, scale int) error {
+	n := (q.size + 2*qrQuietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, n, n))
+	for py := 0; py < n; py++ {
+		for px := 0; px < n; px++ {
+			c := color.Gray{Y: 255}
+			if q.dark(px/scale-qrQuietZone, py/scale-qrQuietZone) {
+				c.Y = 0
+			}
+			img.SetGray(px, py, c)
+		}
+	}
+	return png.Encode(w, img)
+}
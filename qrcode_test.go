@@ -0,0 +1,218 @@
+// qrcode_test.go
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Reference encodings at level M from an independent encoder (rsc.io/qr), with the
+// mask this encoder picks; '#' is a dark module.
+const (
+	qrReferenceV1 = `
+#######.....#.#######
+#.....#...#...#.....#
+#.###.#.##....#.###.#
+#.###.#.####..#.###.#
+#.###.#.#.###.#.###.#
+#.....#.##..#.#.....#
+#######.#.#.#.#######
+........#.###........
+#.#####.....#.#####..
+..#..#.#.#..##.###..#
+#...#.###..#.#....##.
+........#....#.######
+.###.##.##.#..##.....
+........#..##...#..#.
+#######...#.##...###.
+#.....#.##.###.#.##.#
+#.###.#.#.#.#.#..#..#
+#.###.#.#...###.#.#..
+#.###.#.####.#....#..
+#.....#..#......###..
+#######.##.#...#.#.#.
+`
+	qrReferenceV7 = `
+#######.##..#..#...#.....#..###.....#.#######
+#.....#.#..#.#.#...#.#...#.#....##.#..#.....#
+#.###.#..#.#...#...#..##.#.....##..#..#.###.#
+#.###.#.#...#.##..##.##.#....#.#...##.#.###.#
+#.###.#...##.#.##########.#.....#####.#.###.#
+#.....#..#####.#.####...##.#..#.#.....#.....#
+#######.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#######
+........#.#.#....##.#...#####..#.#.#.........
+#.##.###.##.#.############.##....###..#..#.##
+#.##.#.#.#.#...#..#...###.....##...###......#
+#..#..##.#.##.#.##.#####...#.####.##.#.#..###
+#.###.......#.#...##.###.....#.####...#..#.##
+#.##..#.......##.###.##.##.#.###...#...#.....
+..#..#....####..###.########...#.#...###.#...
+.##..#####...#.#..#.#..#.####.#.#..######....
+#.####....#.###..#.#..#..#.##.#.##.###.#####.
+##..####.#.##...#..##..#.###.##.#.####.#.#.##
+#..##..####..#.##.#..##.#..###...###...##..##
+.#.#..##..###.#...#.#.###.##...#.##.####.##..
+.#.###..#####.##.#.###...#...##.#.#.#.#.....#
+....#####...######..###########..#..#####.#..
+###.#...##......#.###...####.##....##...##..#
+###.#.#.##.#.#..##.##.#.#.#..##.#.#.#.#.#.###
+#.#.#...#.....#.##..#...##.....##.#.#...##..#
+#.#########.###.#.#.#####.....##...######....
+....##.#.#.#.##....####..####..#.#..#.##...##
+#..######..#..#.#####.#.##.....##..#....##...
+###....#..#.#####.#.##.##..##.#.#..###.#.##..
+#.##..###.##..#####.##...###...###.####.#.#.#
+#...##..#.#.#...########...###.##.#.##.######
+#..##.##..##.#.#.###.#..#.#......##.#...#.##.
+.#...#..#..###..#.##.##.###.#.#..##.#...#...#
+##.####.#....##..#...##.#..####..#..#.....#.#
+.##.....##.##.#..#.#####.....###....##...####
+....#.##..#.#..#...........#.####.##.###..###
+.####.........#..##..###.#...#.####.######...
+#..##.####........########.#.##.....######.#.
+........#.#.....#...#...#.#.....##.##...##...
+#######.#####.##.#..#.#.#..####..#..#.#.###..
+#.....#.#....#.##.###...######..#...#...#####
+#.###.#..#.##.###...#####.##.#.#############.
+#.###.#.#...#......##.#....#.#..###.#....#..#
+#.###.#.#...##....#.....#.##......####....##.
+#.....#..##.....#....#..#####..##..#####.#..#
+#######.#......##.#..###..#####...#..####.#..
+`
+	qrReferenceLogin = `
+#######....#.#######.#.#.####.###..##....##...#######
+#.....#..#.#....###..##...##...#.###.....###..#.....#
+#.###.#.#..#.#.#.#..#..#.##.....##..#...#..#..#.###.#
+#.###.#.#.#.#.#.##.##......#.###...#...#.##.#.#.###.#
+#.###.#.##.#...#....##############...#.##.#...#.###.#
+#.....#.##..###.##.##...#...#..#####..##..#...#.....#
+#######.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#######
+........#####.#.........#...#.##...#.#.####..........
+#.#####..###.###..#....#######......#....#.##.#####..
+#.###.....##....###...#.#####.##.#.###.#.##..#.#.#..#
+...####...######....##.#.##..#....#.###..#.#..#.##...
+.##..#.##..####..####.#.##...#.####...#.#.#....#.#.#.
+.#.##.####..#..###.###..#.###....#..##......#..##.###
+.#.#.#...#....#.#.#...###..###.#.#..##.#.###...####.#
+#.##..##..#......##......##...#...#####......##..##..
+.#.##....#..#####.#.#..###...#..##..#...#........#.##
+..##.#####......#.#....##.######...###...#####.##.#.#
+##.##....##.#..#.#.#..##.#.##.#..#.#......##.#..#...#
+..##.##.#.#..#...###.#.....#...##...###....#.##...##.
+..#....#.###.#.##..##..##..#.#.##....#######.....#.#.
+###.###.#.#.###...#..#.#..#....#...##.#...#######.##.
+..##.#.####..#.#...#...###...####..#####.###.#.##..##
+..##.####......######.####.#...#..#.#......##.#..##..
+#....#...#...#.#.######.#....##.#.###.########...#..#
+###.#######.#.###.#..#.#########..#.##....#######.##.
+.##.#...##....###..##.#.#...######.###...####...##.##
+.##.#.#.#.#.###.#..#.#..#.#.#....##.#.###...#.#.#....
+###.#...#####.###.###.#.#...###..#.#...####.#...##.##
+#..########..#.......#.######..##.#.#....#.#########.
+.##....#.##...#...#.####.###.##.#....#...##.##.###.##
+#..#.##.##.....###.##...#..##..###..###..#..#........
+.##.##.#...#.##.#...##.#.#....#.#..#....##.#.##.##.##
+.#..###.#.#..##....#....#....###..###.#...#..###..#.#
+.##..#....####...###.##..#.##.#..#.#.#.#..#.#########
+##.#..##.##...#.#.####...###.###..#.#.####..##..##...
+....##.##.##..#....#..###....#..#.##.#.###.####.#...#
+.#.#.####.#..###.#..#...##.#.#.#.##.##...#..##.#..#.#
+#.#....#.#.##.##..##..##..#..####.###..####...###...#
+#.....#.#..#.#...####.#..#.##....#.####.##..#...####.
+#...##...###.#.##..####.###..#####.....####.#.####...
+.##########.####.##.#.#.##.#.###..####...##...##..##.
+#####..##.####.#######.#..##.#####.#....#.#.#.#.##.##
+##.#######.##.##..####...#.###.#.#####..##.......#...
+.##....#.#...####.#..#.#.#...#..#.#.#...#.##.#####.##
+...#..#.#..#.####......######..#..##.###.#..######..#
+........#.#.##..#...###.#...###.#....#.######...##..#
+#######..#.#.....#.#.#..#.#.##.##.##..##...##.#.#....
+#.....#.####...#..#.#..##...####...#...######...##..#
+#.###.#.##.##..###...#.######....##.###..#.########.#
+#.###.#.#....##....##.........##...##..##.#.#.....#..
+#.###.#.##..##.####..###.#####...##...##.....#.###.##
+#.....#....#.#.#.###.#.#...#.#..#....#.###.#.#.#.#.#.
+#######.#..##.#..#.#.#..#.#.#..#.#####...###..##.##..
+`
+)
+
+func qrString(q *qrCode) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	for _, row := range q.modules {
+		for _, dark := range row {
+			if dark {
+				b.WriteString("#")
+			} else {
+				b.WriteString(".")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func TestEncodeQR(t *testing.T) {
+	tests := []struct {
+		name, data string
+		version    int
+		want       string
+	}{
+		{"version 1", "Slipstream", 1, qrReferenceV1},
+		{"version 7, with version information", strings.Repeat("Rocket League ", 8), 7, qrReferenceV7},
+		{"login link", epicLoginRedirect, 9, qrReferenceLogin},
+	}
+	for _, tt := range tests {
+		q, err := encodeQR(tt.data)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if want := tt.version*4 + 17; q.size != want {
+			t.Errorf("%s: size %d, want %d (version %d)", tt.name, q.size, want, tt.version)
+			continue
+		}
+		if got := qrString(q); got != tt.want {
+			t.Errorf("%s: modules differ from the reference:\n got:%s\nwant:%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeQRCapacity(t *testing.T) {
+	// Version 40 at level M holds 2331 bytes.
+	q, err := encodeQR(strings.Repeat("a", 2331))
+	if err != nil || q.size != 177 {
+		t.Errorf("2331 bytes: %v", err)
+	}
+	if _, err := encodeQR(strings.Repeat("a", 2332)); err == nil {
+		t.Error("2332 bytes were encoded, which no QR code version holds at level M")
+	}
+}
+
+func TestQRFormatBits(t *testing.T) {
+	// Format information for level M and masks 0 to 7, from the standard's table.
+	want := []int{0x5412, 0x5125, 0x5E7C, 0x5B4B, 0x45F9, 0x40CE, 0x4F97, 0x4AA0}
+	for mask, w := range want {
+		if got := qrFormatBits(mask); got != w {
+			t.Errorf("qrFormatBits(%d) = %#x, want %#x", mask, got, w)
+		}
+	}
+}
+
+func TestQRVersionBits(t *testing.T) {
+	// Version information, from the standard's table.
+	want := map[int]int{7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3, 21: 0x15683, 40: 0x28C69}
+	for version, w := range want {
+		if got := qrVersionBits(version); got != w {
+			t.Errorf("qrVersionBits(%d) = %#x, want %#x", version, got, w)
+		}
+	}
+}
+
+func TestReedSolomon(t *testing.T) {
+	// The version 1-M example from the standard's annex: "01234567" in numeric mode.
+	data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	want := []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+	if got := reedSolomon(data, 10); string(got) != string(want) {
+		t.Errorf("reedSolomon = % X, want % X", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+// redact.go
+package main
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	redactedValue   = "<redacted>"
+	minSecretLength = 8 // Shorter values are too likely to match ordinary text.
+)
+
+// knownSecrets are token values seen at runtime, masked wherever they appear.
+var knownSecrets struct {
+	sync.RWMutex
+	values []string
+}
+
+// secretPatterns mask token-shaped values and known secret fields, including secrets
+// from earlier runs that were never registered in this one.
+var secretPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	// JSON fields: "refresh_token": "...", also when quoted again inside a log line.
+	{regexp.MustCompile(`(?i)(\\?"(?:access_token|refresh_token|exchange_code|code|authorizationCode|epic_token|password)\\?"\s*:\s*\\?")[^"\\]+`), "${1}" + redactedValue},
+	// Form and query parameters: refresh_token=...
+	{regexp.MustCompile(`(?i)\b((?:access_token|refresh_token|exchange_code|epic_token)=)[^&\s"]+`), "${1}" + redactedValue},
+	// The authorization code in the token request body, before or after its grant type,
+	// and in Epic URLs. Elsewhere "code=" is too common to mask.
+	{regexp.MustCompile(`(?i)\b(code=)[^&\s"]+((?:&[^&\s"]*)*&grant_type=authorization_code\b)`), "${1}" + redactedValue + "${2}"},
+	{regexp.MustCompile(`(?i)(\bgrant_type=authorization_code(?:&[^&\s"]*)*&code=)[^&\s"]+`), "${1}" + redactedValue},
+	{regexp.MustCompile(`(?i)(\bepicgames\.com/[^\s"?]*\?(?:[^\s"]*&)?code=)[^&\s"]+`), "${1}" + redactedValue},
+	// The game's exchange code argument.
+	{regexp.MustCompile(`(?i)(-AUTH_PASSWORD=)"?[^\s"]+"?`), "${1}" + redactedValue},
+	// Authorization headers.
+	{regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]{8,}`), "${1} " + redactedValue},
+	// Epic access and refresh tokens.
+	{regexp.MustCompile(`\beg1~[A-Za-z0-9._~+/=-]+`), redactedValue},
+}
+
+// registerSecret records values that must never be shown, e.g. tokens from Epic's API.
+func registerSecret(values ...string) {
+	knownSecrets.Lock()
+	defer knownSecrets.Unlock()
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len(v) < minSecretLength || containsString(knownSecrets.values, v) {
+			continue
+		}
+		knownSecrets.values = append(knownSecrets.values, v)
+	}
+	// Longest first, so a secret containing another is masked whole.
+	sort.Slice(knownSecrets.values, func(i, j int) bool { return len(knownSecrets.values[i]) > len(knownSecrets.values[j]) })
+}
+
+// redact masks every known secret and secret-shaped value in s.
+func redact(s string) string {
+	knownSecrets.RLock()
+	for _, v := range knownSecrets.values {
+		s = strings.ReplaceAll(s, v, redactedValue)
+	}
+	knownSecrets.RUnlock()
+	for _, p := range secretPatterns {
+		s = p.pattern.ReplaceAllString(s, p.replacement)
+	}
+	return s
+}
+
+// redactingWriter masks secrets in everything written through it. Each Write must
+// hold complete lines, as log and slog records do, so a secret is never split.
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (r *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// copyRedacted copies a text file such as the game's Launch.log, which records the
+// command line including the exchange code, with secrets masked.
+func copyRedacted(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, []byte(redact(string(data))), 0644)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
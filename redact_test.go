@@ -0,0 +1,221 @@
+// redact_test.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`{"access_token": "abcdef123456", "expires_in": 7200}`, `{"access_token": "<redacted>", "expires_in": 7200}`},
+		{`body="{\"refresh_token\":\"abcdef123456\"}"`, `body="{\"refresh_token\":\"<redacted>\"}"`},
+		{"grant_type=refresh_token&refresh_token=abcdef123456&token_type=eg1", "grant_type=refresh_token&refresh_token=<redacted>&token_type=eg1"},
+		// The authorization code in the token request body, in either order.
+		{"code=0123456789abcdef&grant_type=authorization_code", "code=<redacted>&grant_type=authorization_code"},
+		{"grant_type=authorization_code&code=0123456789abcdef", "grant_type=authorization_code&code=<redacted>"},
+		{"https://www.epicgames.com/id/api/redirect?clientId=x&code=0123456789abcdef", "https://www.epicgames.com/id/api/redirect?clientId=x&code=<redacted>"},
+		{"RocketLeague.exe -AUTH_LOGIN=unused -AUTH_PASSWORD=0123456789abcdef -AUTH_TYPE=exchangecode", "RocketLeague.exe -AUTH_LOGIN=unused -AUTH_PASSWORD=<redacted> -AUTH_TYPE=exchangecode"},
+		{"Authorization: bearer 0123456789abcdef", "Authorization: bearer <redacted>"},
+		{"token eg1~abc.DEF-123", "token <redacted>"},
+		// Other uses of "code=" are left alone.
+		{"Process exited with code=3", "Process exited with code=3"},
+		{"https://example.com/callback?code=200&state=ok", "https://example.com/callback?code=200&state=ok"},
+		{"grant_type=client_credentials&code=12345678", "grant_type=client_credentials&code=12345678"},
+	}
+	for _, tt := range tests {
+		if got := redact(tt.in); got != tt.want {
+			t.Errorf("redact(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterSecret(t *testing.T) {
+	registerSecret("short", "opaque-session-secret", "opaque-session-secret-2")
+	got := redact("short opaque-session-secret opaque-session-secret-2")
+	if want := "short <redacted> <redacted>"; got != want {
+		t.Errorf("redact = %q, want %q", got, want)
+	}
+}
+
+// fakeEpic stands in for Epic's account service. It accepts only its own codes and
+// tokens and, like the real service, echoes rejected values in its error messages.
+type fakeEpic struct {
+	authCode     string
+	refreshToken string
+	accessToken  string
+	exchangeCode string
+}
+
+func (f fakeEpic) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reject := func(value string) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(apiResponse{
+			ErrorCode:    "errors.com.epicgames.account.oauth.authorization_code_not_found",
+			ErrorMessage: fmt.Sprintf("Sorry the code %s you provided was not found", value),
+		})
+	}
+	switch r.URL.Path {
+	case tokenPath:
+		if r.Header.Get("Authorization") != epicLauncherAuth {
+			reject(r.Header.Get("Authorization"))
+			return
+		}
+		r.ParseForm()
+		switch r.PostForm.Get("grant_type") {
+		case "authorization_code":
+			if code := r.PostForm.Get("code"); code != f.authCode {
+				reject(code)
+				return
+			}
+		case "refresh_token":
+			if token := r.PostForm.Get("refresh_token"); token != f.refreshToken {
+				reject(token)
+				return
+			}
+		default:
+			reject(r.PostForm.Get("grant_type"))
+			return
+		}
+		json.NewEncoder(w).Encode(apiResponse{
+			AccessToken:  f.accessToken,
+			RefreshToken: f.refreshToken,
+			AccountID:    "0123456789abcdef0123456789abcdef",
+			DisplayName:  "Flip Reset",
+		})
+	case exchangePath:
+		if auth := r.Header.Get("Authorization"); auth != "bearer "+f.accessToken {
+			reject(strings.TrimPrefix(auth, "bearer "))
+			return
+		}
+		json.NewEncoder(w).Encode(apiResponse{Code: f.exchangeCode})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serve starts the fake service and returns an authenticator that uses it.
+func (f fakeEpic) serve(t *testing.T) *Authenticator {
+	t.Helper()
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	a := NewAuthenticator()
+	a.apiURL = server.URL
+	return a
+}
+
+// captureOutput sends stdout and the debug log of the temporary install to files until
+// the test ends, and returns a function that reads everything written to either.
+func captureOutput(t *testing.T) func() string {
+	t.Helper()
+	stdout, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdout, oldLogger := os.Stdout, slog.Default()
+	oldWriter, oldFlags := log.Writer(), log.Flags()
+	os.Stdout = stdout
+	closeLog := setupLogging(Config{}, true)
+	t.Cleanup(func() {
+		closeLog()
+		slog.SetDefault(oldLogger)
+		log.SetOutput(oldWriter)
+		log.SetFlags(oldFlags)
+		os.Stdout = oldStdout
+		stdout.Close()
+	})
+	return func() string {
+		out := readTestFile(t, stdout.Name())
+		return out + readTestFile(t, filepath.Join(getExecutableDir(), logFileName))
+	}
+}
+
+// assertNoSecrets fails the test if any secret shows up in the output or a dialog.
+func assertNoSecrets(t *testing.T, output string, dialogs *scriptedUI, secrets ...string) {
+	t.Helper()
+	for _, secret := range secrets {
+		if strings.Contains(output, secret) {
+			t.Errorf("%q appears in the output:\n%s", secret, output)
+		}
+		for i, message := range dialogs.Messages {
+			if strings.Contains(message, secret) {
+				t.Errorf("%q appears in the %q dialog: %s", secret, dialogs.Shown[i], message)
+			}
+		}
+	}
+}
+
+// TestLoginKeepsSecretsOutOfOutput logs in through a fake Epic account service and
+// launches the game in a dry run, then checks that no code or token was shown.
+func TestLoginKeepsSecretsOutOfOutput(t *testing.T) {
+	exe := useTempInstall(t)
+	output := captureOutput(t)
+	epic := fakeEpic{
+		authCode:     "a1b2c3d4e5f60718293a4b5c6d7e8f90",
+		refreshToken: "eg1~refreshEyJhbGciOiJIUzI1NiJ9.payload.sig",
+		accessToken:  "acc3ss7oken0f7he5ession4l1fetime",
+		exchangeCode: "f00dfacecafebeef0123456789abcdef",
+	}
+	a := epic.serve(t)
+	dialogs := useScriptedUI(t, "Use Phone", epic.authCode)
+
+	creds, token, err := a.GetLaunchCredentials("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != epic.refreshToken || creds.ExchangeCode != epic.exchangeCode || creds.DisplayName != "Flip Reset" {
+		t.Fatalf("GetLaunchCredentials = %+v, %q", creds, token)
+	}
+	cfg := Config{RocketLeaguePath: filepath.Join(filepath.Dir(exe), "RocketLeague")}
+	if _, err := launchGame(cfg, creds, []string{dryRunFlag}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := output()
+	if !strings.Contains(out, "-AUTH_PASSWORD="+redactedValue) {
+		t.Errorf("the launch command was not logged:\n%s", out)
+	}
+	assertNoSecrets(t, out, dialogs, epic.authCode, epic.refreshToken, epic.accessToken, epic.exchangeCode)
+}
+
+// TestRejectedLoginKeepsSecretsOutOfOutput checks the error paths, where Epic's
+// messages repeat the rejected token and code back.
+func TestRejectedLoginKeepsSecretsOutOfOutput(t *testing.T) {
+	useTempInstall(t)
+	output := captureOutput(t)
+	epic := fakeEpic{
+		authCode:     "0f1e2d3c4b5a69788796a5b4c3d2e1f0",
+		refreshToken: "rotatedRefreshTokenValue5678",
+		accessToken:  "anotherAccessTokenValue1234",
+		exchangeCode: "feedc0de0123456789abcdeffeedc0de",
+	}
+	a := epic.serve(t)
+	const expiredToken = "expiredRefreshTokenValue0000"
+	const mistypedCode = "0f1e2d3c4b5a69788796a5b4c3d2e1f1"
+	dialogs := useScriptedUI(t, "Use Phone", mistypedCode)
+
+	_, _, err := a.GetLaunchCredentials(expiredToken)
+	if err == nil {
+		t.Fatal("login succeeded with an expired token and a mistyped code")
+	}
+	showAuthError(err)
+
+	if got := dialogs.Shown[len(dialogs.Shown)-1]; got != "Authentication Failed" {
+		t.Errorf("last dialog = %q, want the authentication error", got)
+	}
+	out := output()
+	if !strings.Contains(out, "was not found") {
+		t.Errorf("Epic's error message was not logged:\n%s", out)
+	}
+	assertNoSecrets(t, out, dialogs, expiredToken, mistypedCode)
+}
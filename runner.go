@@ -0,0 +1,128 @@
+// runner.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// Runner types for RunnerConfig.Type.
+	runnerWine   = "wine"
+	runnerProton = "proton"
+	runnerUmu    = "umu"
+
+	// defaultUmuGameID is Rocket League's umu-protonfixes ID (its Steam app ID).
+	defaultUmuGameID = "umu-252950"
+)
+
+// RunnerConfig describes how the native Linux build runs the Windows game.
+type RunnerConfig struct {
+	Type       string `json:"type,omitempty"`        // "wine", "proton" or "umu"; empty means no runner
+	Path       string `json:"path,omitempty"`        // wine or umu-run binary, or Proton install directory
+	Prefix     string `json:"prefix,omitempty"`      // WINEPREFIX, or the compatdata directory for Proton
+	ProtonPath string `json:"proton_path,omitempty"` // PROTONPATH passed to umu-run
+	GameID     string `json:"game_id,omitempty"`     // GAMEID passed to umu-run
+}
+
+// needsRunner reports whether exe is a Windows executable that can't be started directly.
+func needsRunner(exe string) bool {
+	return runtime.GOOS != "windows" && strings.HasSuffix(strings.ToLower(exe), ".exe")
+}
+
+// winePrefix returns the Wine prefix the runner uses, if known.
+func (r RunnerConfig) winePrefix() string {
+	if r.Prefix == "" {
+		return ""
+	}
+	if strings.ToLower(r.Type) == runnerProton {
+		return filepath.Join(r.Prefix, "pfx")
+	}
+	return r.Prefix
+}
+
+// runnerCommand builds the command that starts exe with args. Windows executables
+// on other systems go through the configured runner; everything else runs directly.
+// Arguments are passed through unchanged.
+func runnerCommand(r RunnerConfig, exe string, args ...string) (*exec.Cmd, error) {
+	if !needsRunner(exe) {
+		return exec.Command(exe, args...), nil
+	}
+
+	winExe := windowsPath(r.winePrefix(), exe)
+	var cmd *exec.Cmd
+	var env []string
+	switch strings.ToLower(r.Type) {
+	case runnerWine:
+		cmd = exec.Command(orDefault(r.Path, "wine"), append([]string{winExe}, args...)...)
+		if r.Prefix != "" {
+			env = append(env, "WINEPREFIX="+r.Prefix)
+		}
+	case runnerProton:
+		if r.Path == "" || r.Prefix == "" {
+			return nil, errors.New("the Proton runner needs both 'path' (the Proton install directory) and 'prefix' (its compatdata directory)")
+		}
+		cmd = exec.Command(filepath.Join(r.Path, "proton"), append([]string{"run", winExe}, args...)...)
+		env = append(env,
+			"STEAM_COMPAT_DATA_PATH="+r.Prefix,
+			"STEAM_COMPAT_CLIENT_INSTALL_PATH="+steamRootDir(),
+		)
+	case runnerUmu:
+		cmd = exec.Command(orDefault(r.Path, "umu-run"), append([]string{winExe}, args...)...)
+		env = append(env, "GAMEID="+orDefault(r.GameID, defaultUmuGameID))
+		if r.Prefix != "" {
+			env = append(env, "WINEPREFIX="+r.Prefix)
+		}
+		if r.ProtonPath != "" {
+			env = append(env, "PROTONPATH="+r.ProtonPath)
+		}
+	case "":
+		return nil, errors.New("no runner is configured for Windows executables")
+	default:
+		return nil, fmt.Errorf("unknown runner type %q (expected wine, proton or umu)", r.Type)
+	}
+
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Dir = filepath.Dir(exe)
+	return cmd, nil
+}
+
+// windowsPath translates a Linux path into the path Wine sees: C: inside the
+// prefix's drive_c, and Z: (the filesystem root) for anything else.
+func windowsPath(prefix, path string) string {
+	path = filepath.Clean(path)
+	if prefix != "" {
+		driveC := filepath.Join(prefix, "drive_c")
+		if rel, err := filepath.Rel(driveC, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return `C:\` + strings.ReplaceAll(rel, "/", `\`)
+		}
+	}
+	return "Z:" + strings.ReplaceAll(path, "/", `\`)
+}
+
+// steamRootDir returns the Steam client install directory Proton expects.
+func steamRootDir() string {
+	home, _ := os.UserHomeDir()
+	for _, dir := range []string{
+		filepath.Join(home, ".steam", "steam"),
+		filepath.Join(home, ".local", "share", "Steam"),
+		filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", "data", "Steam"),
+	} {
+		if fileExists(dir) {
+			return dir
+		}
+	}
+	return filepath.Join(home, ".steam", "steam")
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
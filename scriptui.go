@@ -0,0 +1,121 @@
+// scriptui.go
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+)
+
+// scriptedUI answers dialogs from a list of prepared answers instead of asking, for
+// automated runs and for testing dialog flows. Every dialog is logged.
+//
+// Each answer is used by the next dialog that needs one: "ok", "extra" or "cancel"
+// (or a button label) for questions, and the text, path or item for entries, file
+// selections and lists. An empty answer cancels. Once the answers run out, every
+// dialog is canceled.
+type scriptedUI struct {
+	answers  []string
+	Shown    []string // Titles of all dialogs shown, in order
+	Messages []string // Their messages
+}
+
+func newScriptedUI(answers ...string) *scriptedUI {
+	return &scriptedUI{answers: answers}
+}
+
+// loadScriptedUI reads the answers from a file, one per line.
+func loadScriptedUI(path string) (*scriptedUI, error) {
+	if path == "" {
+		return nil, errors.New(`"ui_script" is not set`)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return newScriptedUI(strings.Split(strings.TrimRight(string(data), "\r\n"), "\n")...), nil
+}
+
+func (s *scriptedUI) show(title, message string) {
+	s.Shown = append(s.Shown, title)
+	s.Messages = append(s.Messages, message)
+}
+
+func (s *scriptedUI) next(kind, title, message string) (string, bool) {
+	s.show(title, message)
+	if len(s.answers) == 0 {
+		log.Printf("Scripted %s %q: no answer left, canceling", kind, title)
+		return "", false
+	}
+	a := strings.TrimRight(s.answers[0], "\r")
+	s.answers = s.answers[1:]
+	// Entries can hold a code the user pastes, which isn't a known secret yet.
+	if kind == "entry" {
+		log.Printf("Scripted %s %q: %d characters", kind, title, len(a))
+	} else {
+		log.Printf("Scripted %s %q: %q", kind, title, a)
+	}
+	return a, a != ""
+}
+
+func (s *scriptedUI) Info(title, message string) {
+	s.show(title, message)
+}
+
+func (s *scriptedUI) Error(title, message string) {
+	s.show(title, message)
+}
+
+func (s *scriptedUI) Question(q question) answer {
+	a, ok := s.next("question", q.Title, q.Message)
+	switch {
+	case !ok:
+		return answerCancel
+	case strings.EqualFold(a, "ok") || strings.EqualFold(a, q.OK):
+		return answerOK
+	case q.Extra != "" && (strings.EqualFold(a, "extra") || strings.EqualFold(a, q.Extra)):
+		return answerExtra
+	}
+	return answerCancel
+}
+
+func (s *scriptedUI) Entry(title, message, value string) (string, error) {
+	return s.value("entry", title, message)
+}
+
+func (s *scriptedUI) SelectFile(title string, filters []fileFilter) (string, error) {
+	return s.value("file selection", title, "")
+}
+
+func (s *scriptedUI) List(title, message string, items []string) (string, error) {
+	a, err := s.value("list", title, message)
+	if err == nil && !containsString(items, a) {
+		return "", errors.New("scripted answer " + a + " is not in the list")
+	}
+	return a, err
+}
+
+func (s *scriptedUI) QRCode(title, message string, code *qrCode, image string) {
+	s.show(title, message)
+}
+
+func (s *scriptedUI) Progress(title string) (progressDialog, error) {
+	s.show(title, "")
+	return scriptedProgress{}, nil
+}
+
+func (s *scriptedUI) value(kind, title, message string) (string, error) {
+	a, ok := s.next(kind, title, message)
+	if !ok {
+		return "", errDialogCanceled
+	}
+	return a, nil
+}
+
+type scriptedProgress struct{}
+
+func (scriptedProgress) Text(string) error { return nil }
+func (scriptedProgress) Value(int) error   { return nil }
+func (scriptedProgress) Complete() error   { return nil }
+func (scriptedProgress) Close() error      { return nil }
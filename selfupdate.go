@@ -0,0 +1,297 @@
+// selfupdate.go
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	checksumsAssetName = "SHA256SUMS"
+	previousSuffix     = ".previous"
+)
+
+// releaseAsset is a file attached to a GitHub release.
+type releaseAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+	Size               int64  `json:"size"`
+}
+
+// updateAssetName returns the release asset built for this system, matching the
+// release workflow, or "" if no build is published for it.
+func updateAssetName() string {
+	if runtime.GOARCH != "amd64" {
+		return ""
+	}
+	switch runtime.GOOS {
+	case "windows":
+		return "Slipstream.exe"
+	case "linux":
+		return "Slipstream"
+	}
+	return ""
+}
+
+func findAsset(release GitHubRelease, name string) (releaseAsset, bool) {
+	for _, a := range release.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return releaseAsset{}, false
+}
+
+// canSelfUpdate reports whether this build can verify updates and the release has a
+// build for this system along with signed checksums.
+func canSelfUpdate(release GitHubRelease) bool {
+	_, hasBinary := findAsset(release, updateAssetName())
+	_, hasSums := findAsset(release, checksumsAssetName)
+	_, hasSig := findAsset(release, signatureAssetName)
+	return updatePublicKey != "" && updateAssetName() != "" && hasBinary && hasSums && hasSig
+}
+
+// selfUpdate downloads the release's build for this system, checks it against the
+// published SHA-256 checksum and replaces the running executable with it. The
+// checksum file itself must carry a valid signature from the embedded public key.
+// The previous executable is kept next to it for the rollback command.
+func selfUpdate(release GitHubRelease) error {
+	name := updateAssetName()
+	asset, ok := findAsset(release, name)
+	if !ok {
+		return fmt.Errorf("release %s has no %s build", release.TagName, name)
+	}
+	sumsAsset, ok := findAsset(release, checksumsAssetName)
+	if !ok {
+		return fmt.Errorf("release %s publishes no %s, so the download can't be verified", release.TagName, checksumsAssetName)
+	}
+	sigAsset, ok := findAsset(release, signatureAssetName)
+	if !ok {
+		return fmt.Errorf("release %s publishes no %s, so the download can't be verified", release.TagName, signatureAssetName)
+	}
+	pub, err := parseMinisignPublicKey(updatePublicKey)
+	if err != nil {
+		return fmt.Errorf("this build's update signing key is unusable: %w", err)
+	}
+
+	exe, err := currentExecutable()
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Minute}
+
+	log.Printf("Downloading checksums for %s...", release.TagName)
+	sums, err := fetchAsset(client, sumsAsset)
+	if err != nil {
+		return err
+	}
+	sig, err := fetchAsset(client, sigAsset)
+	if err != nil {
+		return err
+	}
+	if err := verifyMinisign(pub, sums, sig); err != nil {
+		return fmt.Errorf("the signature of %s is not valid, refusing to update: %w", checksumsAssetName, err)
+	}
+	log.Printf("Signature of %s verified.", checksumsAssetName)
+	want, err := parseChecksum(sums, name)
+	if err != nil {
+		return err
+	}
+
+	// Download next to the executable so the final rename stays on one filesystem.
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".slipstream-update-*")
+	if err != nil {
+		return fmt.Errorf("could not create download file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	log.Printf("Downloading %s...", asset.BrowserDownloadURL)
+	got, err := downloadWithProgress(client, asset, tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	if !strings.EqualFold(got, want) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, want, got)
+	}
+	log.Printf("Checksum verified: %s", got)
+
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	if err := replaceExecutable(exe, tmp.Name(), exe+previousSuffix); err != nil {
+		return fmt.Errorf("could not install the update: %w", err)
+	}
+	log.Printf("Updated Slipstream to %s; the previous version was kept as %s", release.TagName, exe+previousSuffix)
+	return nil
+}
+
+// fetchAsset downloads a small release asset into memory.
+func fetchAsset(client *http.Client, asset releaseAsset) ([]byte, error) {
+	resp, err := client.Get(asset.BrowserDownloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not download %s: %w", asset.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not download %s (status code: %d)", asset.Name, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("could not download %s: %w", asset.Name, err)
+	}
+	return data, nil
+}
+
+// parseChecksum returns the SHA-256 for name from a sha256sum-style checksum file.
+func parseChecksum(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		// Format: <hex digest> <space><space or '*'><file name>
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name && len(fields[0]) == sha256.Size*2 {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("%s has no checksum for %s", checksumsAssetName, name)
+}
+
+// downloadWithProgress writes the asset to w and returns its SHA-256, showing a
+// progress dialog when one can be opened.
+func downloadWithProgress(client *http.Client, asset releaseAsset, w io.Writer) (string, error) {
+	resp, err := client.Get(asset.BrowserDownloadURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	total := resp.ContentLength
+	if total <= 0 {
+		total = asset.Size
+	}
+	progress := &progressWriter{total: total}
+	if dlg, err := ui.Progress("Updating Slipstream"); err == nil {
+		progress.dlg = dlg
+		dlg.Text("Downloading " + asset.Name + "...")
+		defer dlg.Close()
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, hash, progress), resp.Body); err != nil {
+		return "", err
+	}
+	if progress.dlg != nil {
+		progress.dlg.Complete()
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// progressWriter reports download progress to a dialog and the log.
+type progressWriter struct {
+	total   int64
+	written int64
+	percent int
+	dlg     progressDialog
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	if p.total <= 0 {
+		return len(b), nil
+	}
+	percent := int(p.written * 100 / p.total)
+	if percent > p.percent {
+		p.percent = percent
+		if p.dlg != nil {
+			p.dlg.Value(percent)
+		}
+		if percent%25 == 0 {
+			log.Printf("Downloaded %d%%", percent)
+		}
+	}
+	return len(b), nil
+}
+
+// replaceExecutable moves newPath into place as exe, keeping the current exe as backup.
+// On Unix the backup is a hard link and the new file is renamed over exe in a single
+// step. Windows can't replace a running executable, but it can rename it, so the
+// current exe is moved aside first and put back if the new one can't be moved in.
+func replaceExecutable(exe, newPath, backup string) error {
+	if err := os.Remove(backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if runtime.GOOS != "windows" {
+		if err := os.Link(exe, backup); err != nil {
+			if err := copyFile(exe, backup); err != nil {
+				return fmt.Errorf("could not keep the previous version: %w", err)
+			}
+			os.Chmod(backup, 0755)
+		}
+		return os.Rename(newPath, exe)
+	}
+
+	if err := os.Rename(exe, backup); err != nil {
+		return fmt.Errorf("could not keep the previous version: %w", err)
+	}
+	if err := os.Rename(newPath, exe); err != nil {
+		if restoreErr := os.Rename(backup, exe); restoreErr != nil {
+			log.Printf("Warning: could not restore %s: %v", exe, restoreErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// currentExecutable returns the path of the running executable, with symlinks resolved.
+func currentExecutable() (string, error) {
+	exe, err := executablePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+// runRollbackCommand swaps the current executable with the version kept by the last
+// update, so running it again undoes the rollback.
+func runRollbackCommand() error {
+	exe, err := currentExecutable()
+	if err != nil {
+		return err
+	}
+	backup := exe + previousSuffix
+	if !fileExists(backup) {
+		return fmt.Errorf("no previous version found at %s", backup)
+	}
+
+	// Move the previous version aside first so the swap can reuse replaceExecutable.
+	restored := exe + ".rollback"
+	if err := os.Rename(backup, restored); err != nil {
+		return err
+	}
+	if err := replaceExecutable(exe, restored, backup); err != nil {
+		if restoreErr := os.Rename(restored, backup); restoreErr != nil {
+			log.Printf("Warning: could not restore %s: %v", backup, restoreErr)
+		}
+		return err
+	}
+	showInfo("Rollback Complete", "Slipstream was restored to the previous version.\n\n"+
+		"Run 'Slipstream rollback' again to return to the updated version.")
+	return nil
+}
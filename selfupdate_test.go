@@ -0,0 +1,244 @@
+// selfupdate_test.go
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testBuilds are the builds a fake release publishes, by asset name.
+var testBuilds = map[string][]byte{
+	"Slipstream":     []byte("new linux version"),
+	"Slipstream.exe": []byte("new windows version"),
+}
+
+// checksumFile writes a sha256sum-style checksum file for the builds.
+func checksumFile(builds map[string][]byte) []byte {
+	var b strings.Builder
+	for _, name := range sortedKeys(builds) {
+		fmt.Fprintf(&b, "%x  %s\n", sha256.Sum256(builds[name]), name)
+	}
+	return []byte(b.String())
+}
+
+// serveRelease publishes files as the assets of a release on a local server standing
+// in for GitHub, and points releasesURL at it.
+func serveRelease(t *testing.T, tag string, files map[string][]byte) GitHubRelease {
+	t.Helper()
+	release := GitHubRelease{TagName: tag, HTMLURL: "https://example.invalid/releases/" + tag}
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	for _, name := range sortedKeys(files) {
+		data := files[name]
+		mux.HandleFunc("/download/"+name, func(w http.ResponseWriter, r *http.Request) { w.Write(data) })
+		release.Assets = append(release.Assets, releaseAsset{Name: name, BrowserDownloadURL: server.URL + "/download/" + name, Size: int64(len(data))})
+	}
+	mux.HandleFunc("/releases", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"test-etag"`)
+		if r.Header.Get("If-None-Match") == `"test-etag"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		json.NewEncoder(w).Encode([]GitHubRelease{{TagName: "v1.0.0"}, release})
+	})
+
+	old := releasesURL
+	releasesURL = server.URL + "/releases"
+	t.Cleanup(func() { releasesURL = old })
+	return release
+}
+
+// useUpdateKey embeds a new test key as the update signing key.
+func useUpdateKey(t *testing.T) testSigningKey {
+	t.Helper()
+	key := newTestSigningKey(t, "release1")
+	old := updatePublicKey
+	updatePublicKey = key.pub
+	t.Cleanup(func() { updatePublicKey = old })
+	return key
+}
+
+// signedRelease returns the assets of a release of the builds, with signed checksums.
+func signedRelease(key testSigningKey, builds map[string][]byte) map[string][]byte {
+	files := map[string][]byte{}
+	for name, data := range builds {
+		files[name] = data
+	}
+	sums := checksumFile(builds)
+	files[checksumsAssetName] = sums
+	files[signatureAssetName] = key.sign(sums, "timestamp:1760000000\tfile:SHA256SUMS")
+	return files
+}
+
+func requireUpdateAsset(t *testing.T) string {
+	t.Helper()
+	name := updateAssetName()
+	if name == "" {
+		t.Skip("no release build is published for this system")
+	}
+	return name
+}
+
+// TestSelfUpdate updates from a local release server through the update dialog,
+// then rolls the update back and forth.
+func TestSelfUpdate(t *testing.T) {
+	name := requireUpdateAsset(t)
+	exe := useTempInstall(t)
+	writeTestConfig(t, Config{})
+	key := useUpdateKey(t)
+	serveRelease(t, "v99.0.0", signedRelease(key, testBuilds))
+	dialogs := useScriptedUI(t, "Update Now")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	checkForUpdates(Config{}, &wg)
+
+	if got := readTestFile(t, exe); got != string(testBuilds[name]) {
+		t.Fatalf("executable after update = %q, want the %s build", got, name)
+	}
+	if got := readTestFile(t, exe+previousSuffix); got != "current version" {
+		t.Errorf("kept previous version = %q", got)
+	}
+	if info, err := os.Stat(exe); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("updated executable is not executable: %v, %v", info.Mode(), err)
+	}
+	if want := []string{"Update Available", "Updating Slipstream", "Update Complete"}; !slices.Equal(dialogs.Shown, want) {
+		t.Errorf("dialogs = %q, want %q", dialogs.Shown, want)
+	}
+	cfg, _ := readConfigFile()
+	if cfg.LastNotifiedVersion != "v99.0.0" || cfg.UpdateETag != `"test-etag"` {
+		t.Errorf("saved update state = %q, %q", cfg.LastNotifiedVersion, cfg.UpdateETag)
+	}
+
+	if err := runRollbackCommand(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, exe); got != "current version" {
+		t.Errorf("executable after rollback = %q", got)
+	}
+	if got := readTestFile(t, exe+previousSuffix); got != string(testBuilds[name]) {
+		t.Errorf("kept version after rollback = %q", got)
+	}
+	if err := runRollbackCommand(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestFile(t, exe); got != string(testBuilds[name]) {
+		t.Errorf("executable after undoing the rollback = %q", got)
+	}
+	assertNoDownloadsLeft(t, exe)
+}
+
+func TestSelfUpdateRefusesChecksumMismatch(t *testing.T) {
+	name := requireUpdateAsset(t)
+	exe := useTempInstall(t)
+	useScriptedUI(t)
+	key := useUpdateKey(t)
+	files := signedRelease(key, testBuilds)
+	files[name] = []byte("tampered build")
+	release := serveRelease(t, "v99.0.0", files)
+
+	err := selfUpdate(release)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("selfUpdate error = %v, want a checksum mismatch", err)
+	}
+	assertNotUpdated(t, exe)
+}
+
+func TestSelfUpdateRefusesBadSignature(t *testing.T) {
+	requireUpdateAsset(t)
+	exe := useTempInstall(t)
+	useScriptedUI(t)
+	useUpdateKey(t)
+	release := serveRelease(t, "v99.0.0", signedRelease(newTestSigningKey(t, "release1"), testBuilds))
+
+	err := selfUpdate(release)
+	if err == nil || !strings.Contains(err.Error(), "refusing to update") {
+		t.Fatalf("selfUpdate error = %v, want a signature error", err)
+	}
+	assertNotUpdated(t, exe)
+}
+
+func TestCanSelfUpdate(t *testing.T) {
+	name := requireUpdateAsset(t)
+	useUpdateKey(t)
+	release := func(names ...string) GitHubRelease {
+		r := GitHubRelease{TagName: "v99.0.0"}
+		for _, n := range names {
+			r.Assets = append(r.Assets, releaseAsset{Name: n})
+		}
+		return r
+	}
+	tests := []struct {
+		name    string
+		release GitHubRelease
+		want    bool
+	}{
+		{"signed release", release(name, checksumsAssetName, signatureAssetName), true},
+		{"no signature", release(name, checksumsAssetName), false},
+		{"no checksums", release(name, signatureAssetName), false},
+		{"no build for this system", release(checksumsAssetName, signatureAssetName), false},
+	}
+	for _, tt := range tests {
+		if got := canSelfUpdate(tt.release); got != tt.want {
+			t.Errorf("%s: canSelfUpdate = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	updatePublicKey = ""
+	if canSelfUpdate(tests[0].release) {
+		t.Error("a build without an update signing key offered to update itself")
+	}
+}
+
+func TestRollbackWithoutPreviousVersion(t *testing.T) {
+	exe := useTempInstall(t)
+	useScriptedUI(t)
+	if err := runRollbackCommand(); err == nil {
+		t.Fatal("rollback succeeded without a previous version")
+	}
+	assertNotUpdated(t, exe)
+}
+
+func TestParseChecksum(t *testing.T) {
+	sums := checksumFile(testBuilds)
+	for name, data := range testBuilds {
+		got, err := parseChecksum(sums, name)
+		if want := fmt.Sprintf("%x", sha256.Sum256(data)); err != nil || got != want {
+			t.Errorf("parseChecksum(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	// Binary-mode entries are marked with '*'.
+	if _, err := parseChecksum([]byte(fmt.Sprintf("%x *Slipstream\n", sha256.Sum256(nil))), "Slipstream"); err != nil {
+		t.Error(err)
+	}
+	if _, err := parseChecksum(sums, "Slipstream-arm64"); err == nil {
+		t.Error("found a checksum for a build that isn't listed")
+	}
+}
+
+func assertNotUpdated(t *testing.T, exe string) {
+	t.Helper()
+	if got := readTestFile(t, exe); got != "current version" {
+		t.Errorf("executable was changed to %q", got)
+	}
+	if fileExists(exe + previousSuffix) {
+		t.Error("a previous version was kept although nothing was updated")
+	}
+	assertNoDownloadsLeft(t, exe)
+}
+
+func assertNoDownloadsLeft(t *testing.T, exe string) {
+	t.Helper()
+	if left, _ := filepath.Glob(filepath.Join(filepath.Dir(exe), ".slipstream-update-*")); len(left) > 0 {
+		t.Errorf("downloads left behind: %q", left)
+	}
+}
@@ -0,0 +1,113 @@
+// semver.go
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// semVersion is a parsed semantic version (https://semver.org). Build metadata is ignored.
+type semVersion struct {
+	Major, Minor, Patch int
+	Prerelease          []string // Dot-separated identifiers after '-', e.g. ["beta", "2"]
+}
+
+// parseSemver parses tags like "v1.10.0", "1.8.0-beta.2" and "v2.0.0-rc.1+build.5".
+// A leading 'v' is optional, and missing minor or patch numbers count as zero so that
+// tags like "v1.8" are accepted. It returns false for anything else.
+func parseSemver(tag string) (semVersion, bool) {
+	s := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(tag), "v"), "V")
+	s, _, _ = strings.Cut(s, "+")
+	core, pre, hasPre := strings.Cut(s, "-")
+
+	var v semVersion
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return v, false
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, ok := parseSemverNumber(part)
+		if !ok {
+			return v, false
+		}
+		*nums[i] = n
+	}
+
+	if hasPre {
+		v.Prerelease = strings.Split(pre, ".")
+		for _, id := range v.Prerelease {
+			if id == "" || strings.IndexFunc(id, func(r rune) bool {
+				return !(r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+			}) >= 0 {
+				return v, false
+			}
+		}
+	}
+	return v, true
+}
+
+// parseSemverNumber parses a version number, which must be all digits without a leading zero.
+func parseSemverNumber(s string) (int, bool) {
+	if s == "" || len(s) > 1 && s[0] == '0' {
+		return 0, false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+func (v semVersion) isPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// compare returns -1, 0 or 1 following semver precedence: a prerelease sorts before
+// its release, numeric identifiers compare numerically and before alphanumeric ones,
+// and a shorter set of otherwise equal identifiers sorts first.
+func (v semVersion) compare(o semVersion) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case !v.isPrerelease() && !o.isPrerelease():
+		return 0
+	case !v.isPrerelease():
+		return 1
+	case !o.isPrerelease():
+		return -1
+	}
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		a, b := v.Prerelease[i], o.Prerelease[i]
+		an, aNum := parseSemverNumber(a)
+		bn, bNum := parseSemverNumber(b)
+		switch {
+		case aNum && bNum:
+			if an != bn {
+				return sign(an - bn)
+			}
+		case aNum:
+			return -1
+		case bNum:
+			return 1
+		case a != b:
+			return sign(strings.Compare(a, b))
+		}
+	}
+	return sign(len(v.Prerelease) - len(o.Prerelease))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,133 @@
+// semver_test.go
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want semVersion
+		ok   bool
+	}{
+		{"v1.10.0", semVersion{1, 10, 0, nil}, true},
+		{"1.8.0-beta.2", semVersion{1, 8, 0, []string{"beta", "2"}}, true},
+		{"v2.0.0-rc.1+build.5", semVersion{2, 0, 0, []string{"rc", "1"}}, true},
+		{"v1.8", semVersion{1, 8, 0, nil}, true},
+		{"V3", semVersion{3, 0, 0, nil}, true},
+		{" v1.2.3 ", semVersion{1, 2, 3, nil}, true},
+		{"v1.2.3-x-y.0a", semVersion{1, 2, 3, []string{"x-y", "0a"}}, true},
+		// Malformed tags.
+		{"", semVersion{}, false},
+		{"latest", semVersion{}, false},
+		{"v1.2.3.4", semVersion{}, false},
+		{"v1..3", semVersion{}, false},
+		{"v01.2.3", semVersion{}, false},
+		{"v1.2.x", semVersion{}, false},
+		{"v1.2.3-", semVersion{}, false},
+		{"v1.2.3-beta..1", semVersion{}, false},
+		{"v1.2.3-beta_1", semVersion{}, false},
+		{"v-1.2.3", semVersion{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSemver(tt.tag)
+		if ok != tt.ok || ok && (got.Major != tt.want.Major || got.Minor != tt.want.Minor || got.Patch != tt.want.Patch || !slices.Equal(got.Prerelease, tt.want.Prerelease)) {
+			t.Errorf("parseSemver(%q) = %+v, %v; want %+v, %v", tt.tag, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.10.0", "v1.9.0", 1},
+		{"v1.9.9", "v1.10.0", -1},
+		{"v2.0.0", "v1.99.99", 1},
+		{"v1.8", "v1.8.0", 0},
+		{"v1.0.1", "v1.0.0", 1},
+		// Prerelease ordering from the semver spec.
+		{"v1.0.0-alpha", "v1.0.0-alpha.1", -1},
+		{"v1.0.0-alpha.1", "v1.0.0-alpha.beta", -1},
+		{"v1.0.0-alpha.beta", "v1.0.0-beta", -1},
+		{"v1.0.0-beta", "v1.0.0-beta.2", -1},
+		{"v1.0.0-beta.2", "v1.0.0-beta.11", -1},
+		{"v1.0.0-beta.11", "v1.0.0-rc.1", -1},
+		{"v1.0.0-rc.1", "v1.0.0", -1},
+		{"v1.0.0", "v1.0.0-rc.1", 1},
+		{"v1.1.0-alpha", "v1.0.0", 1},
+		// Numeric identifiers sort before alphanumeric ones.
+		{"v1.0.0-1", "v1.0.0-a", -1},
+		{"v1.0.0-9", "v1.0.0-10", -1},
+		{"v1.0.0-10a", "v1.0.0-9a", -1},
+		// Build metadata is ignored.
+		{"v1.0.0+build.1", "v1.0.0+build.2", 0},
+		{"v1.0.0-rc.1+a", "v1.0.0-rc.1+b", 0},
+		{"v1.0.1+old", "v1.0.0+new", 1},
+	}
+	for _, tt := range tests {
+		a, okA := parseSemver(tt.a)
+		b, okB := parseSemver(tt.b)
+		if !okA || !okB {
+			t.Fatalf("could not parse %q or %q", tt.a, tt.b)
+		}
+		if got := a.compare(b); got != tt.want {
+			t.Errorf("compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := b.compare(a); got != -tt.want {
+			t.Errorf("compare(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestIsNewerVersion(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		want            bool
+	}{
+		{"v1.9.0", "v1.10.0", true},
+		{"v1.10.0", "v1.9.0", false},
+		{"v1.7.0", "v1.7.0", false},
+		{"v1.7.0", "v1.8.0-beta.1", true},
+		{"v1.8.0-beta.1", "v1.8.0", true},
+		{"v1.8.0", "v1.8.0-beta.1", false},
+		{"v1.7.0", "nightly", false},
+		{"dev", "v1.8.0", false},
+	}
+	for _, tt := range tests {
+		if got := isNewerVersion(tt.current, tt.latest); got != tt.want {
+			t.Errorf("isNewerVersion(%q, %q) = %v, want %v", tt.current, tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestNewestRelease(t *testing.T) {
+	releases := []GitHubRelease{
+		{TagName: "v1.9.0"},
+		{TagName: "v1.10.0"},
+		{TagName: "v1.11.0-beta.1"},
+		{TagName: "v1.11.0-rc.1", Prerelease: true},
+		{TagName: "v1.12.0", Draft: true},
+		{TagName: "v1.10.1", Prerelease: true},
+		{TagName: "not-a-version"},
+	}
+	tests := []struct {
+		channel, want string
+	}{
+		{updateChannelStable, "v1.10.0"},
+		{"", "v1.10.0"},
+		{updateChannelBeta, "v1.11.0-rc.1"},
+	}
+	for _, tt := range tests {
+		got, ok := newestRelease(releases, tt.channel)
+		if !ok || got.TagName != tt.want {
+			t.Errorf("newestRelease(%q) = %q, %v; want %q", tt.channel, got.TagName, ok, tt.want)
+		}
+	}
+	if _, ok := newestRelease([]GitHubRelease{{TagName: "v2.0.0-beta.1"}}, updateChannelStable); ok {
+		t.Error("stable channel offered a prerelease")
+	}
+}
@@ -0,0 +1,278 @@
+// settings.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const settingsTitle = "Slipstream Settings"
+
+// rocketLeagueExeNames are the executables accepted as rocket_league_path.
+var rocketLeagueExeNames = []string{"RocketLeague_EAC.exe", "RocketLeague.exe", "RocketLeague"}
+
+const (
+	settingsSave    = "Save and close"
+	settingsDiscard = "Close without saving"
+)
+
+// settingsItems are the entries of the settings menu, each with the editor that
+// changes that part of the config.
+var settingsItems = []struct {
+	label string
+	edit  func(cfg *Config)
+}{
+	{"Game path", editGamePath},
+	{"Easy Anti-Cheat (online or offline)", editEACMode},
+	{"Launch arguments", editLaunchArgs},
+	{"Companion apps", editCompanions},
+	{"Update channel", editUpdateChannel},
+	{"Log in again", editLogin},
+}
+
+// runSettingsCommand opens the settings editor for the active config file and saves
+// the changes, so users never need to edit config.json by hand.
+func runSettingsCommand() error {
+	cfg, err := readConfigFile()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s could not be read: %w", getConfigFileName(), err)
+	}
+	if !editSettings(&cfg) {
+		log.Println("Settings closed without saving.")
+		return nil
+	}
+	if err := saveConfig(cfg); err != nil {
+		return err
+	}
+	log.Println("Settings saved.")
+	return nil
+}
+
+// editSettings shows the settings menu until the user saves or closes it, and
+// reports whether cfg was changed and should be saved.
+func editSettings(cfg *Config) bool {
+	edited := *cfg
+	// The editors change these in place, so they must not share cfg's arrays.
+	edited.LaunchArgs = slices.Clone(cfg.LaunchArgs)
+	edited.Companions = slices.Clone(cfg.Companions)
+
+	labels := []string{}
+	for _, item := range settingsItems {
+		labels = append(labels, item.label)
+	}
+	labels = append(labels, settingsSave, settingsDiscard)
+	for {
+		choice, err := ui.List(settingsTitle, "Choose a setting to change:", labels)
+		switch {
+		case err != nil:
+			// Closing the menu with unsaved changes asks before dropping them.
+			if reflect.DeepEqual(*cfg, edited) || askQuestion(question{Title: settingsTitle, Message: "Save your changes?", OK: "Save", Cancel: "Discard"}) != answerOK {
+				return false
+			}
+			fallthrough
+		case choice == settingsSave:
+			*cfg = edited
+			return true
+		case choice == settingsDiscard:
+			return false
+		}
+		for _, item := range settingsItems {
+			if item.label == choice {
+				item.edit(&edited)
+			}
+		}
+	}
+}
+
+func editGamePath(cfg *Config) {
+	showInfo("Game Path", "Current game path:\n"+orDefault(cfg.RocketLeaguePath, "(not set)")+
+		"\n\nSelect RocketLeague_EAC.exe (usually found in Binaries/Win64).")
+	path, err := ui.SelectFile("Select RocketLeague_EAC.exe", []fileFilter{
+		{Name: "Rocket League Executable", Patterns: rocketLeagueExeNames},
+		{Name: "All Files", Patterns: []string{"*"}},
+	})
+	if err != nil {
+		return
+	}
+	if err := validateGamePath(path); err != nil {
+		showError("Invalid Game Path", err.Error())
+		return
+	}
+	cfg.RocketLeaguePath = path
+}
+
+func validateGamePath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%s could not be opened: %w", path, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a folder; select RocketLeague_EAC.exe inside Binaries/Win64", path)
+	}
+	for _, name := range rocketLeagueExeNames {
+		if strings.EqualFold(filepath.Base(path), name) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not Rocket League; select RocketLeague_EAC.exe inside Binaries/Win64", filepath.Base(path))
+}
+
+// editEACMode sets whether the game starts with Easy Anti-Cheat by default, using
+// the -noeac launch argument.
+func editEACMode(cfg *Config) {
+	online, offline := "Online (with Easy Anti-Cheat)", "Offline (without Easy Anti-Cheat)"
+	choice, err := ui.List("Easy Anti-Cheat", "How should Rocket League start by default?\n\n"+
+		"Offline mode only allows Free Play, Replays and Custom Training. A launch option can still choose per shortcut.",
+		[]string{online, offline})
+	if err != nil {
+		return
+	}
+	cfg.LaunchArgs = withoutNoEAC(cfg.LaunchArgs)
+	if choice == offline {
+		cfg.LaunchArgs = append(cfg.LaunchArgs, "-noeac")
+	}
+}
+
+func editLaunchArgs(cfg *Config) {
+	// -noeac is managed by the Easy Anti-Cheat setting.
+	current := withoutNoEAC(cfg.LaunchArgs)
+	text, err := ui.Entry("Launch Arguments", "Arguments passed to Rocket League on every launch, separated by spaces (e.g. -nomovie):", strings.Join(current, " "))
+	if err != nil {
+		return
+	}
+	args := strings.Fields(text)
+	if err := validateLaunchArgs(args); err != nil {
+		showError("Invalid Launch Arguments", err.Error())
+		return
+	}
+	if len(current) < len(cfg.LaunchArgs) {
+		args = append(args, "-noeac")
+	}
+	cfg.LaunchArgs = args
+}
+
+func withoutNoEAC(args []string) []string {
+	var kept []string
+	for _, arg := range args {
+		if !strings.EqualFold(arg, "-noeac") {
+			kept = append(kept, arg)
+		}
+	}
+	return kept
+}
+
+// validateLaunchArgs rejects arguments that aren't flags and the login arguments
+// Slipstream sets itself.
+func validateLaunchArgs(args []string) error {
+	for _, arg := range args {
+		switch {
+		case !strings.HasPrefix(strings.TrimPrefix(arg, "!"), "-"):
+			return fmt.Errorf("%q is not a launch argument; arguments start with '-'", arg)
+		case isLoginArg(arg):
+			return fmt.Errorf("%q is set by Slipstream when it logs you in and can't be changed", arg)
+		}
+	}
+	return nil
+}
+
+// editCompanions lists the companion apps to turn on or off, remove, or add.
+func editCompanions(cfg *Config) {
+	const add = "Add a companion app..."
+	for {
+		items := []string{}
+		for _, c := range cfg.Companions {
+			state := "on"
+			if c.Disabled {
+				state = "off"
+			}
+			items = append(items, fmt.Sprintf("%s (%s)", c.Name, state))
+		}
+		items = append(items, add)
+		choice, err := ui.List("Companion Apps", "Choose a companion app to change:", items)
+		if err != nil {
+			return
+		}
+		if choice == add {
+			addCompanion(cfg)
+			continue
+		}
+		for i := range cfg.Companions {
+			if items[i] == choice {
+				editCompanion(cfg, i)
+				break
+			}
+		}
+	}
+}
+
+func editCompanion(cfg *Config, i int) {
+	c := &cfg.Companions[i]
+	toggle := "Turn Off"
+	if c.Disabled {
+		toggle = "Turn On"
+	}
+	switch askQuestion(question{Title: c.Name, Message: c.Name + "\n" + c.Path, OK: toggle, Extra: "Remove", Cancel: "Back"}) {
+	case answerOK:
+		c.Disabled = !c.Disabled
+	case answerExtra:
+		// Removing BakkesMod answers the setup question, so it isn't asked again.
+		if strings.EqualFold(c.Name, bakkesModName) {
+			cfg.BakkesModSetupDeclined = true
+		}
+		cfg.Companions = append(cfg.Companions[:i], cfg.Companions[i+1:]...)
+	}
+}
+
+func addCompanion(cfg *Config) {
+	path, err := ui.SelectFile("Select the Companion App", []fileFilter{
+		{Name: "Programs", Patterns: []string{"*.exe", "*.sh", "*"}},
+	})
+	if err != nil {
+		return
+	}
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		showError("Invalid Companion App", path+" is not a program.")
+		return
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	name, err = ui.Entry("Companion App Name", "Name shown in the log:", name)
+	name = strings.TrimSpace(name)
+	if err != nil || name == "" {
+		return
+	}
+	if findCompanion(cfg.Companions, name) != nil {
+		showError("Invalid Companion App", fmt.Sprintf("There already is a companion app named %q.", name))
+		return
+	}
+	cfg.Companions = append(cfg.Companions, Companion{Name: name, Path: path})
+}
+
+func editUpdateChannel(cfg *Config) {
+	stable, beta := "Stable releases", "Beta releases too"
+	choice, err := ui.List("Update Channel", "Which versions should Slipstream tell you about?", []string{stable, beta})
+	if err != nil {
+		return
+	}
+	cfg.UpdateChannel = ""
+	if choice == beta {
+		cfg.UpdateChannel = updateChannelBeta
+	}
+}
+
+// editLogin runs the Epic login and replaces the saved login. No exchange code is
+// requested, so the next launch works as usual.
+func editLogin(cfg *Config) {
+	token, err := NewAuthenticator().performFirstTimeSetup()
+	if err != nil {
+		showError("Login Failed", "Your saved login was not changed.\n\nDetails: "+err.Error())
+		return
+	}
+	cfg.EpicToken = token
+	showInfo("Logged In", "You are logged in. Choose \"Save and close\" to keep the new login.")
+}
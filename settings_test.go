@@ -0,0 +1,19 @@
+// settings_test.go
+package main
+
+import "testing"
+
+func TestRemoveCompanion(t *testing.T) {
+	for _, name := range []string{bakkesModName, "OBS"} {
+		cfg := Config{Companions: []Companion{{Name: "Recorder", Path: "/usr/bin/recorder"}, {Name: name, Path: "/games/" + name + ".exe"}}}
+		useScriptedUI(t, "Remove")
+		editCompanion(&cfg, 1)
+
+		if len(cfg.Companions) != 1 || cfg.Companions[0].Name != "Recorder" {
+			t.Errorf("companions after removing %s = %+v", name, cfg.Companions)
+		}
+		if want := name == bakkesModName; cfg.BakkesModSetupDeclined != want {
+			t.Errorf("after removing %s, BakkesModSetupDeclined = %v, want %v", name, cfg.BakkesModSetupDeclined, want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// signature.go
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// updatePublicKey is the minisign public key release checksums are signed with.
+// Release builds set it with -ldflags "-X main.updatePublicKey=RW...". Builds
+// without a key can't update themselves.
+var updatePublicKey string
+
+const (
+	signatureAssetName = checksumsAssetName + ".minisig"
+
+	minisignAlgorithm       = "Ed" // Signs the message itself ("minisign -l")
+	minisignHashedAlgorithm = "ED" // Signs a BLAKE2b hash of the message
+	trustedCommentPrefix    = "trusted comment: "
+)
+
+// minisignPublicKey is an ed25519 public key in minisign's format.
+type minisignPublicKey struct {
+	keyID [8]byte
+	key   ed25519.PublicKey
+}
+
+// minisignSignature is a parsed minisign signature file.
+type minisignSignature struct {
+	algorithm       string
+	keyID           [8]byte
+	signature       []byte
+	trustedComment  string
+	globalSignature []byte
+}
+
+// parseMinisignPublicKey parses a public key given either as its base64 line or as
+// the contents of a minisign .pub file.
+func parseMinisignPublicKey(s string) (minisignPublicKey, error) {
+	var pub minisignPublicKey
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil || len(data) != 2+8+ed25519.PublicKeySize || string(data[:2]) != minisignAlgorithm {
+		return pub, errors.New("invalid minisign public key")
+	}
+	copy(pub.keyID[:], data[2:10])
+	pub.key = ed25519.PublicKey(data[10:])
+	return pub, nil
+}
+
+// parseMinisignSignature parses a minisign signature file: an untrusted comment, the
+// signature, a trusted comment and the global signature over signature and comment.
+func parseMinisignSignature(data []byte) (minisignSignature, error) {
+	var sig minisignSignature
+	lines := strings.Split(strings.ReplaceAll(strings.TrimSpace(string(data)), "\r\n", "\n"), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], trustedCommentPrefix) {
+		return sig, errors.New("invalid minisign signature file")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return sig, errors.New("invalid minisign signature")
+	}
+	sig.algorithm = string(raw[:2])
+	copy(sig.keyID[:], raw[2:10])
+	sig.signature = raw[10:]
+	sig.trustedComment = strings.TrimPrefix(lines[2], trustedCommentPrefix)
+	sig.globalSignature, err = base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(sig.globalSignature) != ed25519.SignatureSize {
+		return sig, errors.New("invalid minisign trusted comment signature")
+	}
+	return sig, nil
+}
+
+// verifyMinisign checks a detached minisign signature of message, including the
+// signature over its trusted comment.
+func verifyMinisign(pub minisignPublicKey, message, sigFile []byte) error {
+	sig, err := parseMinisignSignature(sigFile)
+	if err != nil {
+		return err
+	}
+	switch {
+	case sig.algorithm == minisignHashedAlgorithm:
+		return errors.New("prehashed minisign signatures are not supported; sign with 'minisign -S -l'")
+	case sig.algorithm != minisignAlgorithm:
+		return fmt.Errorf("unknown signature algorithm %q", sig.algorithm)
+	case sig.keyID != pub.keyID:
+		return fmt.Errorf("signed with key %X, expected key %X", sig.keyID, pub.keyID)
+	case !ed25519.Verify(pub.key, message, sig.signature):
+		return errors.New("signature does not match")
+	case !ed25519.Verify(pub.key, append(bytes.Clone(sig.signature), sig.trustedComment...), sig.globalSignature):
+		return errors.New("trusted comment signature does not match")
+	}
+	return nil
+}
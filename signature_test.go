@@ -0,0 +1,101 @@
+// signature_test.go
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testSigningKey is a minisign key pair generated for a test.
+type testSigningKey struct {
+	keyID [8]byte
+	priv  ed25519.PrivateKey
+	pub   string // Contents of the .pub file
+}
+
+func newTestSigningKey(t *testing.T, keyID string) testSigningKey {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := testSigningKey{priv: priv}
+	copy(k.keyID[:], keyID)
+	raw := append(append([]byte(minisignAlgorithm), k.keyID[:]...), pub...)
+	k.pub = fmt.Sprintf("untrusted comment: minisign public key %X\n%s\n", k.keyID, base64.StdEncoding.EncodeToString(raw))
+	return k
+}
+
+// sign writes a minisign signature file for message, as "minisign -S -l" does.
+func (k testSigningKey) sign(message []byte, trustedComment string) []byte {
+	return k.signAs(minisignAlgorithm, message, trustedComment)
+}
+
+func (k testSigningKey) signAs(algorithm string, message []byte, trustedComment string) []byte {
+	sig := ed25519.Sign(k.priv, message)
+	raw := append(append([]byte(algorithm), k.keyID[:]...), sig...)
+	global := ed25519.Sign(k.priv, append(bytes.Clone(sig), trustedComment...))
+	return []byte(fmt.Sprintf("untrusted comment: signature from minisign secret key\n%s\n%s%s\n%s\n",
+		base64.StdEncoding.EncodeToString(raw), trustedCommentPrefix, trustedComment, base64.StdEncoding.EncodeToString(global)))
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	key := newTestSigningKey(t, "slipkey1")
+	other := newTestSigningKey(t, "otherkey")
+	pub, err := parseMinisignPublicKey(key.pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("0123abcd  Slipstream-linux-amd64\n4567ef01  Slipstream-windows-amd64.exe\n")
+	const comment = "timestamp:1760000000\tfile:SHA256SUMS"
+	good := key.sign(message, comment)
+
+	tamperedComment := bytes.Replace(good, []byte("timestamp:1760000000"), []byte("timestamp:1860000000"), 1)
+	// A different key that claims this key's ID.
+	impostor := newTestSigningKey(t, "slipkey1")
+
+	tests := []struct {
+		name    string
+		message []byte
+		sig     []byte
+		want    string // Expected error, "" for a valid signature
+	}{
+		{"good signature", message, good, ""},
+		{"good signature, CRLF line endings", message, bytes.ReplaceAll(good, []byte("\n"), []byte("\r\n")), ""},
+		{"wrong key ID", message, other.sign(message, comment), "signed with key 6F746865726B6579"},
+		{"other key with this key ID", message, impostor.sign(message, comment), "signature does not match"},
+		{"tampered file", append(bytes.Clone(message), "89ab  evil\n"...), good, "signature does not match"},
+		{"tampered trusted comment", message, tamperedComment, "trusted comment signature does not match"},
+		{"prehashed signature", message, key.signAs(minisignHashedAlgorithm, message, comment), "prehashed minisign signatures are not supported"},
+		{"unknown algorithm", message, key.signAs("Xx", message, comment), `unknown signature algorithm "Xx"`},
+		{"not a signature", message, []byte("hello\n"), "invalid minisign signature file"},
+	}
+	for _, tt := range tests {
+		err := verifyMinisign(pub, tt.message, tt.sig)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestParseMinisignPublicKey(t *testing.T) {
+	key := newTestSigningKey(t, "slipkey1")
+	for _, s := range []string{key.pub, strings.Split(key.pub, "\n")[1]} {
+		pub, err := parseMinisignPublicKey(s)
+		if err != nil || pub.keyID != key.keyID || !pub.key.Equal(key.priv.Public()) {
+			t.Errorf("parseMinisignPublicKey(%q) = %X, %v", s, pub.keyID, err)
+		}
+	}
+	for _, s := range []string{"", "RWQ", base64.StdEncoding.EncodeToString([]byte("Ed0123456789"))} {
+		if _, err := parseMinisignPublicKey(s); err == nil {
+			t.Errorf("parseMinisignPublicKey(%q) accepted an invalid key", s)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// terminalui.go
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// terminalUI asks on the terminal, for systems without a graphical session or any
+// dialog program (e.g. over SSH).
+type terminalUI struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newTerminalUI(in io.Reader, out io.Writer) *terminalUI {
+	return &terminalUI{in: bufio.NewReader(in), out: out}
+}
+
+func (t *terminalUI) header(title string) {
+	fmt.Fprintf(t.out, "\n== %s ==\n", title)
+}
+
+// readLine returns the next line of input, or errDialogCanceled at end of input.
+func (t *terminalUI) readLine(prompt string) (string, error) {
+	fmt.Fprint(t.out, prompt)
+	line, err := t.in.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(t.out)
+		return "", errDialogCanceled
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func (t *terminalUI) Info(title, message string) {
+	t.header(title)
+	fmt.Fprintln(t.out, message)
+	t.readLine("Press Enter to continue...")
+}
+
+func (t *terminalUI) Error(title, message string) {
+	t.header("Error: " + title)
+	fmt.Fprintln(t.out, message)
+	t.readLine("Press Enter to continue...")
+}
+
+type terminalChoice struct {
+	label  string
+	answer answer
+}
+
+func (t *terminalUI) Question(q question) answer {
+	t.header(q.Title)
+	fmt.Fprintln(t.out, q.Message)
+	choices := []terminalChoice{{orDefault(q.OK, "OK"), answerOK}}
+	if q.Extra != "" {
+		choices = append(choices, terminalChoice{q.Extra, answerExtra})
+	}
+	choices = append(choices, terminalChoice{orDefault(q.Cancel, "Cancel"), answerCancel})
+
+	def := 1
+	if q.DefaultCancel {
+		def = len(choices)
+	}
+	for i, c := range choices {
+		fmt.Fprintf(t.out, "  %d) %s\n", i+1, c.label)
+	}
+	for {
+		line, err := t.readLine(fmt.Sprintf("Choose [%d]: ", def))
+		if err != nil {
+			return answerCancel
+		}
+		if line == "" {
+			return choices[def-1].answer
+		}
+		for i, c := range choices {
+			if line == strconv.Itoa(i+1) || strings.EqualFold(line, c.label) {
+				return c.answer
+			}
+		}
+	}
+}
+
+// Entry keeps value if the user just presses Enter.
+func (t *terminalUI) Entry(title, message, value string) (string, error) {
+	t.header(title)
+	if value == "" {
+		return t.readLine(message + " ")
+	}
+	line, err := t.readLine(fmt.Sprintf("%s [%s] ", message, value))
+	if err == nil && line == "" {
+		return value, nil
+	}
+	return line, err
+}
+
+func (t *terminalUI) SelectFile(title string, filters []fileFilter) (string, error) {
+	t.header(title)
+	if len(filters) > 0 {
+		fmt.Fprintf(t.out, "Expected: %s\n", strings.Join(filters[0].Patterns, ", "))
+	}
+	path, err := t.readLine("Path: ")
+	if err == nil && path == "" {
+		return "", errDialogCanceled
+	}
+	return path, err
+}
+
+func (t *terminalUI) List(title, message string, items []string) (string, error) {
+	t.header(title)
+	fmt.Fprintln(t.out, message)
+	for i, item := range items {
+		fmt.Fprintf(t.out, "  %d) %s\n", i+1, item)
+	}
+	for {
+		line, err := t.readLine("Choose: ")
+		if err != nil {
+			return "", err
+		}
+		if line == "" {
+			return "", errDialogCanceled
+		}
+		if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(items) {
+			return items[n-1], nil
+		}
+	}
+}
+
+func (t *terminalUI) QRCode(title, message string, code *qrCode, image string) {
+	t.header(title)
+	fmt.Fprint(t.out, code.terminalArt())
+	fmt.Fprintln(t.out, message)
+	t.readLine("Press Enter to continue...")
+}
+
+func (t *terminalUI) Progress(title string) (progressDialog, error) {
+	t.header(title)
+	return &terminalProgress{out: t.out}, nil
+}
+
+// terminalProgress redraws a single progress line.
+type terminalProgress struct {
+	out io.Writer
+}
+
+func (p *terminalProgress) Text(text string) error {
+	fmt.Fprintln(p.out, text)
+	return nil
+}
+
+func (p *terminalProgress) Value(v int) error {
+	fmt.Fprintf(p.out, "\r%3d%%", v)
+	return nil
+}
+
+func (p *terminalProgress) Complete() error {
+	fmt.Fprintln(p.out, "\r100%")
+	return nil
+}
+
+func (p *terminalProgress) Close() error { return nil }
@@ -0,0 +1,361 @@
+// ui.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/ncruces/zenity"
+)
+
+// Backends for Config.UI.
+const (
+	uiAuto     = "auto"
+	uiZenity   = "zenity"
+	uiKDialog  = "kdialog"
+	uiTerminal = "terminal"
+	uiScript   = "script"
+)
+
+// zenityPrograms are the dialog programs the zenity package can drive on Linux.
+var zenityPrograms = []string{"zenity", "qarma", "matedialog"}
+
+// errDialogCanceled is returned when the user closes or cancels a dialog.
+var errDialogCanceled = errors.New("dialog canceled")
+
+// answer is the button the user chose in a question dialog.
+type answer int
+
+const (
+	answerCancel answer = iota // Cancel button, or the dialog was closed
+	answerOK
+	answerExtra
+)
+
+type dialogIcon int
+
+const (
+	iconQuestion dialogIcon = iota
+	iconInfo
+	iconWarning
+	iconError
+)
+
+// question describes a dialog with up to three buttons.
+type question struct {
+	Title         string
+	Message       string
+	OK            string // Defaults to "OK"
+	Cancel        string // Defaults to "Cancel"
+	Extra         string // Optional third button
+	Icon          dialogIcon
+	DefaultCancel bool // Make Cancel the default button
+}
+
+type fileFilter struct {
+	Name     string
+	Patterns []string // Matched case-insensitively
+}
+
+// progressDialog is an open progress dialog. Value takes a percentage.
+type progressDialog interface {
+	Text(string) error
+	Value(int) error
+	Complete() error
+	Close() error
+}
+
+// uiBackend shows Slipstream's dialogs. All user interaction goes through it, so
+// systems without zenity can use another backend and flows can be scripted.
+type uiBackend interface {
+	Info(title, message string)
+	Error(title, message string)
+	Question(q question) answer
+	Entry(title, message, value string) (string, error) // value is the initial text
+	SelectFile(title string, filters []fileFilter) (string, error)
+	List(title, message string, items []string) (string, error)
+	Progress(title string) (progressDialog, error)
+	QRCode(title, message string, code *qrCode, image string) // Shows a code to scan with a phone; image is its PNG, or ""
+}
+
+// ui is the active backend, chosen by setupUI.
+var ui uiBackend = newLockedUI(zenityUI{})
+
+// lockedUI shows one dialog at a time. Dialogs are opened from several goroutines,
+// such as the Launch.log watcher, companion apps and the update checker, and backends
+// like the terminal share their input between dialogs.
+type lockedUI struct {
+	mu      *sync.Mutex
+	backend uiBackend
+}
+
+func newLockedUI(backend uiBackend) lockedUI {
+	return lockedUI{mu: &sync.Mutex{}, backend: backend}
+}
+
+func (l lockedUI) Info(title, message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.backend.Info(title, message)
+}
+
+func (l lockedUI) Error(title, message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.backend.Error(title, message)
+}
+
+func (l lockedUI) Question(q question) answer {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.backend.Question(q)
+}
+
+func (l lockedUI) Entry(title, message, value string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.backend.Entry(title, message, value)
+}
+
+func (l lockedUI) SelectFile(title string, filters []fileFilter) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.backend.SelectFile(title, filters)
+}
+
+func (l lockedUI) List(title, message string, items []string) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.backend.List(title, message, items)
+}
+
+func (l lockedUI) QRCode(title, message string, code *qrCode, image string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.backend.QRCode(title, message, code, image)
+}
+
+// Progress doesn't hold the lock while the dialog is open, so other dialogs can be
+// shown during a download; each update is serialized instead.
+func (l lockedUI) Progress(title string) (progressDialog, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	dlg, err := l.backend.Progress(title)
+	if err != nil {
+		return nil, err
+	}
+	return lockedProgress{mu: l.mu, dlg: dlg}, nil
+}
+
+type lockedProgress struct {
+	mu  *sync.Mutex
+	dlg progressDialog
+}
+
+func (p lockedProgress) Text(text string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dlg.Text(text)
+}
+
+func (p lockedProgress) Value(v int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dlg.Value(v)
+}
+
+func (p lockedProgress) Complete() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dlg.Complete()
+}
+
+func (p lockedProgress) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.dlg.Close()
+}
+
+// setupUI picks the dialog backend from the "ui" setting. If the chosen backend
+// can't be used, it falls back to zenity.
+func setupUI(cfg Config) {
+	name := chooseUIBackend(cfg)
+	backend, err := newUIBackend(name, cfg)
+	if err != nil {
+		log.Printf("Warning: could not use the %s dialogs, falling back to zenity: %v", name, err)
+		name, backend = uiZenity, zenityUI{}
+	}
+	log.Printf("Using %s dialogs.", name)
+	ui = newLockedUI(backend)
+}
+
+// chooseUIBackend returns the configured backend, or for "auto" the first one that
+// works here: zenity where it is native or installed, then kdialog, then the terminal.
+func chooseUIBackend(cfg Config) string {
+	name := strings.ToLower(cfg.UI)
+	if name != "" && name != uiAuto {
+		return name
+	}
+	if runtime.GOOS != "linux" {
+		return uiZenity
+	}
+	graphical := os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+	if graphical && findProgram(zenityPrograms...) != "" {
+		return uiZenity
+	}
+	if graphical && findProgram(uiKDialog) != "" {
+		return uiKDialog
+	}
+	if isTerminal(os.Stdin) {
+		return uiTerminal
+	}
+	return uiZenity
+}
+
+func newUIBackend(name string, cfg Config) (uiBackend, error) {
+	switch name {
+	case uiZenity:
+		return zenityUI{}, nil
+	case uiKDialog:
+		if findProgram(uiKDialog) == "" {
+			return nil, errors.New("kdialog is not installed")
+		}
+		return kdialogUI{}, nil
+	case uiTerminal:
+		return newTerminalUI(os.Stdin, os.Stderr), nil
+	case uiScript:
+		return loadScriptedUI(cfg.UIScript)
+	}
+	return nil, errors.New("unknown backend")
+}
+
+// findProgram returns the path of the first of the programs found on PATH, or "".
+func findProgram(names ...string) string {
+	for _, name := range names {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// saveQRImage writes the code to a new PNG file in the temp folder and returns its
+// path. The file name is random, so other users of a shared temp folder can't plant
+// a file or symlink in its place. The caller removes the file.
+func saveQRImage(code *qrCode) (string, error) {
+	f, err := os.CreateTemp("", "slipstream-login-qr-*.png")
+	if err != nil {
+		return "", err
+	}
+	if err := code.writePNG(f, 8); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// --- zenity ---
+
+// zenityUI uses the zenity package: native dialogs on Windows and macOS, and the
+// zenity, qarma or matedialog program on Linux.
+type zenityUI struct{}
+
+func (zenityUI) Info(title, message string) {
+	zenity.Info(message, zenity.Title(title), zenity.InfoIcon)
+}
+
+func (zenityUI) Error(title, message string) {
+	zenity.Error(message, zenity.Title(title), zenity.ErrorIcon)
+}
+
+func (zenityUI) Question(q question) answer {
+	opts := []zenity.Option{zenity.Title(q.Title), zenityIcon(q.Icon)}
+	if q.OK != "" {
+		opts = append(opts, zenity.OKLabel(q.OK))
+	}
+	if q.Cancel != "" {
+		opts = append(opts, zenity.CancelLabel(q.Cancel))
+	}
+	if q.Extra != "" {
+		opts = append(opts, zenity.ExtraButton(q.Extra))
+	}
+	if q.DefaultCancel {
+		opts = append(opts, zenity.DefaultCancel())
+	}
+	err := zenity.Question(q.Message, opts...)
+	switch {
+	case err == nil:
+		return answerOK
+	case errors.Is(err, zenity.ErrExtraButton):
+		return answerExtra
+	}
+	return answerCancel
+}
+
+func (zenityUI) Entry(title, message, value string) (string, error) {
+	return zenityResult(zenity.Entry(message, zenity.Title(title), zenity.EntryText(value)))
+}
+
+func (zenityUI) SelectFile(title string, filters []fileFilter) (string, error) {
+	var zf zenity.FileFilters
+	for _, f := range filters {
+		zf = append(zf, zenity.FileFilter{Name: f.Name, Patterns: f.Patterns, CaseFold: true})
+	}
+	return zenityResult(zenity.SelectFile(zenity.Title(title), zf))
+}
+
+func (zenityUI) List(title, message string, items []string) (string, error) {
+	return zenityResult(zenity.List(message, items, zenity.Title(title), zenity.DisallowEmpty()))
+}
+
+func (zenityUI) Progress(title string) (progressDialog, error) {
+	return zenity.Progress(zenity.Title(title), zenity.MaxValue(100))
+}
+
+// QRCode opens the code in the image viewer next to the message: zenity dialogs
+// can only show small icons, which a phone can't scan. In case no viewer opens,
+// the message names the image, which is kept until the code has been entered, and
+// the code is printed to the console.
+func (z zenityUI) QRCode(title, message string, code *qrCode, image string) {
+	fmt.Print(code.terminalArt())
+	if image != "" {
+		openBrowser(image)
+		message += "\n\nIf the code doesn't open, open this image yourself:\n" + image
+	}
+	z.Info(title, message)
+}
+
+func zenityIcon(icon dialogIcon) zenity.Option {
+	switch icon {
+	case iconInfo:
+		return zenity.InfoIcon
+	case iconWarning:
+		return zenity.WarningIcon
+	case iconError:
+		return zenity.ErrorIcon
+	}
+	return zenity.QuestionIcon
+}
+
+func zenityResult(s string, err error) (string, error) {
+	if errors.Is(err, zenity.ErrCanceled) {
+		return "", errDialogCanceled
+	}
+	return s, err
+}
@@ -0,0 +1,206 @@
+// ui_test.go
+package main
+
+import (
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFirstTimeSetupFlow(t *testing.T) {
+	useTempInstall(t)
+	epic := fakeEpic{
+		authCode:     "5e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b",
+		refreshToken: "setupFlowRefreshToken",
+		accessToken:  "setupFlowAccessToken",
+		exchangeCode: "setupFlowExchangeCode",
+	}
+	tests := []struct {
+		name    string
+		answers []string
+		shown   []string
+		wantErr string
+	}{
+		{"phone login", []string{"Use Phone", epic.authCode}, []string{"Authorization Required", "Log In With Your Phone", "Enter Authorization Code"}, ""},
+		{"canceled", []string{"Cancel"}, []string{"Authorization Required"}, "user cancelled login"},
+		{"code entry canceled", []string{"Use Phone", ""}, []string{"Authorization Required", "Log In With Your Phone", "Enter Authorization Code"}, "user cancelled input"},
+		{"short code", []string{"Use Phone", "5e7f8a9b"}, []string{"Authorization Required", "Log In With Your Phone", "Enter Authorization Code"}, "must be 32 characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialogs := useScriptedUI(t, tt.answers...)
+			token, err := epic.serve(t).performFirstTimeSetup()
+			switch {
+			case tt.wantErr == "" && (err != nil || token != epic.refreshToken):
+				t.Errorf("performFirstTimeSetup = %q, %v; want the refresh token", token, err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("performFirstTimeSetup error = %v, want %q", err, tt.wantErr)
+			}
+			if !slices.Equal(dialogs.Shown, tt.shown) {
+				t.Errorf("dialogs = %q, want %q", dialogs.Shown, tt.shown)
+			}
+		})
+	}
+}
+
+// qrImageUI checks that the login QR image is there until the code has been entered.
+type qrImageUI struct {
+	*scriptedUI
+	t     *testing.T
+	image string
+}
+
+func (u *qrImageUI) QRCode(title, message string, code *qrCode, image string) {
+	u.image = image
+	u.scriptedUI.QRCode(title, message, code, image)
+}
+
+func (u *qrImageUI) Entry(title, message, value string) (string, error) {
+	if !fileExists(u.image) {
+		u.t.Errorf("the QR code image %q is gone before the code is entered", u.image)
+	}
+	return u.scriptedUI.Entry(title, message, value)
+}
+
+func TestLoginQRImageLifetime(t *testing.T) {
+	useTempInstall(t)
+	epic := fakeEpic{authCode: "0123456789abcdef0123456789abcdef", refreshToken: "qrFlowRefreshToken"}
+	u := &qrImageUI{scriptedUI: newScriptedUI("Use Phone", epic.authCode), t: t}
+	old := ui
+	ui = newLockedUI(u)
+	defer func() { ui = old }()
+
+	if _, err := epic.serve(t).performFirstTimeSetup(); err != nil {
+		t.Fatal(err)
+	}
+	if u.image == "" {
+		t.Fatal("no QR code image was saved")
+	}
+	if fileExists(u.image) {
+		t.Errorf("the QR code image %q was left behind", u.image)
+	}
+}
+
+func TestConfirmEAC(t *testing.T) {
+	rlDir := t.TempDir() // No EAC files at all.
+	cfg := Config{RocketLeaguePath: filepath.Join(rlDir, "RocketLeague")}
+	tests := []struct {
+		name   string
+		cfg    Config
+		dryRun bool
+		answer string
+		want   eacDecision
+		asked  bool
+	}{
+		{"play offline", cfg, false, "Play Offline", eacOffline, true},
+		{"launch anyway", cfg, false, "Launch Anyway", eacLaunch, true},
+		{"cancel", cfg, false, "Cancel", eacCancel, true},
+		{"dialog closed", cfg, false, "", eacCancel, true},
+		{"dry run", cfg, true, "", eacLaunch, false},
+		{"check turned off", Config{RocketLeaguePath: cfg.RocketLeaguePath, EACCheck: eacCheckOff}, false, "", eacLaunch, false},
+	}
+	for _, tt := range tests {
+		dialogs := useScriptedUI(t, tt.answer)
+		if got := confirmEAC(tt.cfg, rlDir, tt.dryRun); got != tt.want {
+			t.Errorf("%s: confirmEAC = %v, want %v", tt.name, got, tt.want)
+		}
+		if asked := slices.Equal(dialogs.Shown, []string{"Easy Anti-Cheat Problem"}); asked != tt.asked {
+			t.Errorf("%s: dialogs = %q", tt.name, dialogs.Shown)
+		}
+	}
+}
+
+func TestConfirmRestart(t *testing.T) {
+	crash := gameExit{Kind: exitCrash, Detail: "exit code 3", Uptime: 95 * time.Second}
+	tests := []struct {
+		name     string
+		cfg      Config
+		restarts int
+		answer   string
+		want     bool
+		shown    string
+	}{
+		{"relaunch", Config{}, 0, "Relaunch", true, "Rocket League Crashed"},
+		{"close", Config{CrashRestart: crashRestartAsk}, 0, "Close", false, "Rocket League Crashed"},
+		{"automatic", Config{CrashRestart: crashRestartAuto}, 1, "", true, ""},
+		{"too many restarts", Config{CrashRestart: crashRestartAuto}, defaultMaxRestarts, "", false, "Rocket League Crashed"},
+		{"more restarts allowed", Config{MaxRestarts: 5}, defaultMaxRestarts, "Relaunch", true, "Rocket League Crashed"},
+		{"turned off", Config{CrashRestart: crashRestartOff}, 0, "", false, "Rocket League Crashed"},
+	}
+	for _, tt := range tests {
+		dialogs := useScriptedUI(t, tt.answer)
+		if got := confirmRestart(tt.cfg, crash, "/tmp/crash-report.zip", tt.restarts); got != tt.want {
+			t.Errorf("%s: confirmRestart = %v, want %v", tt.name, got, tt.want)
+		}
+		if shown := strings.Join(dialogs.Shown, ", "); shown != tt.shown {
+			t.Errorf("%s: dialogs = %q, want %q", tt.name, shown, tt.shown)
+		}
+		if tt.shown != "" && !strings.Contains(dialogs.Messages[0], "crashed after 1m35s (exit code 3)") {
+			t.Errorf("%s: message = %q", tt.name, dialogs.Messages[0])
+		}
+	}
+}
+
+// TestConcurrentDialogs opens dialogs from several goroutines at once, as the log
+// watcher, companion apps and the update checker do. Run with -race.
+func TestConcurrentDialogs(t *testing.T) {
+	var answers []string
+	for i := 0; i < 10; i++ {
+		answers = append(answers, "ok")
+	}
+	dialogs := useScriptedUI(t, answers...)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			showError("Companion App Failed", fmt.Sprintf("companion %d", i))
+		}()
+		go func() {
+			defer wg.Done()
+			if askQuestion(question{Title: "Update Available", Message: "v99.0.0"}) != answerOK {
+				t.Error("a question didn't get its answer")
+			}
+		}()
+	}
+	wg.Wait()
+	if len(dialogs.Shown) != 20 || len(dialogs.Messages) != 20 {
+		t.Errorf("%d dialogs shown, want 20", len(dialogs.Shown))
+	}
+}
+
+func TestSaveQRImage(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+	t.Setenv("TMP", dir)
+	code, err := encodeQR(epicLoginRedirect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := saveQRImage(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := saveQRImage(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second || filepath.Dir(first) != dir || !strings.HasPrefix(filepath.Base(first), "slipstream-login-qr-") {
+		t.Errorf("saveQRImage paths = %q, %q; want new files in %s", first, second, dir)
+	}
+	f, err := os.Open(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := png.Decode(f); err != nil {
+		t.Errorf("%s is not a PNG: %v", first, err)
+	}
+}
@@ -0,0 +1,148 @@
+// verify.go
+package main
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// verifyProblem is a file that does not match the manifest.
+type verifyProblem struct {
+	Filename string
+	Reason   string
+}
+
+// verifyReport summarises a verification run.
+type verifyReport struct {
+	Checked  int
+	Missing  []verifyProblem
+	Modified []verifyProblem
+}
+
+func (r verifyReport) ok() bool {
+	return len(r.Missing) == 0 && len(r.Modified) == 0
+}
+
+// verifyInstall hashes every file listed in the manifest under root and reports
+// any that are missing or differ. Files are only ever opened for reading.
+func verifyInstall(root string, m *epicManifest, workers int) verifyReport {
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan manifestFile)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	report := verifyReport{Checked: len(m.Files)}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				missing, reason := verifyFile(root, f)
+				if reason == "" {
+					continue
+				}
+				mu.Lock()
+				if missing {
+					report.Missing = append(report.Missing, verifyProblem{f.Filename, reason})
+				} else {
+					report.Modified = append(report.Modified, verifyProblem{f.Filename, reason})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, f := range m.Files {
+		jobs <- f
+	}
+	close(jobs)
+	wg.Wait()
+	return report
+}
+
+// verifyFile checks one file, returning a reason if it does not match the manifest.
+func verifyFile(root string, f manifestFile) (missing bool, reason string) {
+	path := filepath.Join(root, filepath.FromSlash(strings.ReplaceAll(f.Filename, `\`, "/")))
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return true, "file is missing"
+	}
+	if err != nil {
+		return false, err.Error()
+	}
+	defer file.Close()
+
+	if info, err := file.Stat(); err == nil && info.Size() != f.Size() {
+		return false, fmt.Sprintf("size is %d bytes, expected %d", info.Size(), f.Size())
+	}
+	h := sha1.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return false, err.Error()
+	}
+	if [20]byte(h.Sum(nil)) != f.SHA1 {
+		return false, "SHA-1 hash does not match"
+	}
+	return false, ""
+}
+
+// runVerifyCommand verifies the configured game install against its Epic manifest
+// and shows the result. It returns an error if the check could not run or failed.
+func runVerifyCommand() error {
+	report, err := verifyConfiguredInstall()
+	if err != nil {
+		showError("Verification Failed", "Could not verify the game files.\n\nDetails: "+err.Error())
+		return err
+	}
+
+	for _, p := range report.Missing {
+		log.Printf("MISSING: %s", p.Filename)
+	}
+	for _, p := range report.Modified {
+		log.Printf("MODIFIED: %s (%s)", p.Filename, p.Reason)
+	}
+
+	if report.ok() {
+		showInfo("Verification Complete", fmt.Sprintf("All %d game files match the manifest.", report.Checked))
+		return nil
+	}
+	showError("Verification Failed", fmt.Sprintf(
+		"%d of %d game files are missing and %d are modified. See 'slipstream.log' for the list.\n\n"+
+			"Slipstream does not modify game files. Use the Epic Games Launcher or Heroic to repair the install.",
+		len(report.Missing), report.Checked, len(report.Modified)))
+	return fmt.Errorf("%d of %d game files are missing and %d are modified", len(report.Missing), report.Checked, len(report.Modified))
+}
+
+func verifyConfiguredInstall() (verifyReport, error) {
+	cfg, err := readConfigFile()
+	if err != nil {
+		return verifyReport{}, fmt.Errorf("could not read config: %w", err)
+	}
+	if cfg.RocketLeaguePath == "" {
+		return verifyReport{}, errors.New("no Rocket League path is configured; launch Slipstream once to set it up")
+	}
+
+	info, err := findInstallInfo(cfg.RocketLeaguePath)
+	if err != nil {
+		return verifyReport{}, err
+	}
+	if info.ManifestPath == "" {
+		return verifyReport{}, fmt.Errorf("no Epic manifest is referenced by %s", info.Source)
+	}
+	m, err := readEpicManifestFile(info.ManifestPath)
+	if err != nil {
+		return verifyReport{}, fmt.Errorf("could not read manifest %s: %w", info.ManifestPath, err)
+	}
+
+	root := gameInstallDir(cfg.RocketLeaguePath)
+	log.Printf("Verifying %d files of %s %s in %s...", len(m.Files), m.AppName, m.BuildVersion, root)
+	return verifyInstall(root, m, runtime.NumCPU()), nil
+}
@@ -0,0 +1,67 @@
+// verify_test.go
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeTestInstall creates the files under root, with paths given as in the manifest.
+func writeTestInstall(t *testing.T, root string, files []testManifestFile) {
+	t.Helper()
+	for _, f := range files {
+		path := filepath.Join(root, filepath.FromSlash(strings.ReplaceAll(f.Name, `\`, "/")))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, f.Data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestVerifyInstall(t *testing.T) {
+	m, err := parseEpicManifest(buildTestManifest(testGameFiles, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	writeTestInstall(t, root, testGameFiles)
+	if report := verifyInstall(root, m, 2); !report.ok() || report.Checked != len(testGameFiles) {
+		t.Fatalf("intact install: report = %+v", report)
+	}
+
+	writeTestInstall(t, root, []testManifestFile{
+		{`Binaries\Win64\RocketLeague.exe`, []byte("game executablf")},    // same size, different content
+		{"TAGame/CookedPCConsole/Park_P.upk", []byte("stadium, patched")}, // different size
+	})
+	os.Remove(filepath.Join(root, "Engine", "Content", "Ünïcode.txt"))
+
+	report := verifyInstall(root, m, 3)
+	var missing, modified []string
+	for _, p := range report.Missing {
+		missing = append(missing, p.Filename)
+	}
+	for _, p := range report.Modified {
+		modified = append(modified, p.Filename+": "+p.Reason)
+	}
+	slices.Sort(modified)
+
+	if want := []string{"Engine/Content/Ünïcode.txt"}; !slices.Equal(missing, want) {
+		t.Errorf("missing = %q, want %q", missing, want)
+	}
+	want := []string{
+		`Binaries\Win64\RocketLeague.exe: SHA-1 hash does not match`,
+		"TAGame/CookedPCConsole/Park_P.upk: size is 16 bytes, expected 7",
+	}
+	if !slices.Equal(modified, want) {
+		t.Errorf("modified = %q, want %q", modified, want)
+	}
+	if report.ok() {
+		t.Error("report.ok() = true for a damaged install")
+	}
+}